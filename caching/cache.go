@@ -0,0 +1,478 @@
+package caching
+
+import (
+	"runtime"
+	"sync"
+	"time"
+	"weak"
+
+	"github.com/kashifkhan0771/utils/clock"
+)
+
+// CacheOptions configures a Cache and the caching wrappers that take options.
+type CacheOptions[K comparable, V any] struct {
+	// Capacity is the maximum number of entries. 0 or less means unbounded.
+	Capacity int
+	// Policy creates the eviction policy for the given capacity, e.g. NewLRU,
+	// NewLFU or NewARC. Defaults to NewLRU.
+	Policy func(capacity int) Policy[K]
+	// OnEvict is called with every entry evicted to make room for a new one,
+	// after the cache has been unlocked. It is not called for Delete, Clear or
+	// expired entries.
+	OnEvict func(key K, value V)
+
+	// TTL is how long entries stay fresh. 0 or less means they never expire.
+	TTL time.Duration
+	// StaleWhileRevalidate is how long after expiring an entry is still served
+	// by GetOrLoad, while a single goroutine loads a fresh value in the background.
+	// 0 or less means expired entries are loaded again before being served.
+	StaleWhileRevalidate time.Duration
+	// ErrorTTL is how long GetOrLoad caches the error of a failed load, so a
+	// failing key is not loaded again on every call. 0 or less means errors are not cached.
+	ErrorTTL time.Duration
+	// CleanupInterval is how often a background janitor removes expired entries.
+	// 0 or less means expired entries are only removed when they are looked up.
+	CleanupInterval time.Duration
+	// Clock is the time source for expiry. Defaults to the real clock.
+	Clock clock.Clock
+}
+
+// CacheStats counts the lookups, evictions and expirations of a cache.
+type CacheStats struct {
+	Hits        uint64 // Lookups that found an entry, including stale ones served while revalidating
+	Misses      uint64 // Lookups that found no usable entry
+	Evictions   uint64 // Entries evicted to make room for new ones
+	Expirations uint64 // Expired entries removed
+}
+
+// Cache is a thread-safe key-value cache, optionally bounded by a capacity and
+// with expiring entries. When a bounded cache is full, adding an entry evicts
+// the one chosen by its Policy.
+type Cache[K comparable, V any] struct {
+	mu      sync.Mutex
+	store   store[K, V]
+	janitor *janitorStop // nil without a janitor
+}
+
+// store is the state of a cache, without locking. opts and clock never change.
+type store[K comparable, V any] struct {
+	items  map[K]*entry[V]
+	policy Policy[K] // nil if unbounded
+	opts   CacheOptions[K, V]
+	clock  clock.Clock
+	stats  CacheStats
+}
+
+// entry is a cached value, or the error of a failed load.
+type entry[V any] struct {
+	value      V
+	err        error     // Error cached by GetOrLoad for ErrorTTL
+	expires    time.Time // When the entry goes stale, zero if it never does
+	refreshing bool      // Whether a goroutine is loading a fresh value
+}
+
+// Lookup results.
+const (
+	missing = iota // No entry, or one too old to serve
+	stale          // Expired, but within StaleWhileRevalidate
+	fresh          // Not expired
+)
+
+// janitorStop stops a janitor once, from Close or when the cache is garbage collected.
+type janitorStop struct {
+	once sync.Once
+	ch   chan struct{}
+}
+
+// NewCache creates a Cache with the given options.
+// If opts.CleanupInterval > 0, a janitor goroutine removes expired entries
+// until Close is called or the cache is garbage collected.
+func NewCache[K comparable, V any](opts CacheOptions[K, V]) *Cache[K, V] {
+	c := &Cache[K, V]{store: newStore(opts)}
+	if opts.CleanupInterval > 0 {
+		c.startJanitor(opts.CleanupInterval)
+	}
+
+	return c
+}
+
+// Get returns the fresh value cached for key, and whether it was found.
+// A hit counts as a use of the key for the eviction policy.
+func (c *Cache[K, V]) Get(key K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.store.get(key, c.store.clock.Now())
+}
+
+// Peek returns the fresh value cached for key, and whether it was found,
+// without counting as a use of the key or updating the stats.
+func (c *Cache[K, V]) Peek(key K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var zero V
+	e, ok := c.store.items[key]
+	if !ok || e.err != nil || c.store.state(e, c.store.clock.Now()) != fresh {
+		return zero, false
+	}
+
+	return e.value, true
+}
+
+// Set caches value for key for the TTL of the options, replacing any previous value.
+// If the cache is full, the entry chosen by the policy is evicted.
+func (c *Cache[K, V]) Set(key K, value V) {
+	c.SetWithTTL(key, value, c.store.opts.TTL)
+}
+
+// SetWithTTL caches value for key for ttl, replacing any previous value.
+// If ttl <= 0, the entry never expires.
+// If the cache is full, the entry chosen by the policy is evicted.
+func (c *Cache[K, V]) SetWithTTL(key K, value V, ttl time.Duration) {
+	c.mu.Lock()
+	evicted, evictedValue, ok := c.store.set(key, &entry[V]{value: value}, ttl, c.store.clock.Now())
+	c.mu.Unlock()
+
+	if ok && c.store.opts.OnEvict != nil {
+		c.store.opts.OnEvict(evicted, evictedValue)
+	}
+}
+
+// GetOrLoad returns the value cached for key, or calls load and caches its result.
+// An error of load is returned and, if ErrorTTL is set, cached and returned by
+// later calls until it expires.
+// With StaleWhileRevalidate set, an expired value is still returned for that long
+// while a single background goroutine calls load to refresh it. A failed refresh
+// keeps the stale value, and the next call tries again.
+// Concurrent calls for a key that is not cached may each call load.
+func (c *Cache[K, V]) GetOrLoad(key K, load func(key K) (V, error)) (V, error) {
+	c.mu.Lock()
+	e, state := c.store.lookup(key, c.store.clock.Now())
+
+	switch {
+	case state == fresh:
+		c.store.hit(key)
+		c.mu.Unlock()
+
+		return e.value, e.err
+	case state == stale:
+		c.store.hit(key)
+		if !e.refreshing {
+			e.refreshing = true
+			go c.refresh(key, e, load)
+		}
+		c.mu.Unlock()
+
+		return e.value, nil
+	}
+
+	c.store.stats.Misses++
+	c.mu.Unlock()
+
+	value, err := load(key)
+	c.storeLoaded(key, value, err)
+
+	return value, err
+}
+
+// Delete removes the entry for key. Returns true if there was one.
+func (c *Cache[K, V]) Delete(key K) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.store.delete(key)
+}
+
+// DeleteExpired removes the entries that are too old to be served, and returns how many were removed.
+// The janitor calls it every CleanupInterval.
+func (c *Cache[K, V]) DeleteExpired() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.store.deleteExpired(c.store.clock.Now())
+}
+
+// Len returns the number of cached entries, including expired entries that have not been removed yet.
+func (c *Cache[K, V]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return len(c.store.items)
+}
+
+// Capacity returns the maximum number of entries, or 0 if the cache is unbounded.
+func (c *Cache[K, V]) Capacity() int {
+	return max(c.store.opts.Capacity, 0)
+}
+
+// Clear removes all entries and resets the eviction policy. The stats are kept.
+func (c *Cache[K, V]) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.store.clear()
+}
+
+// Stats returns the hits, misses, evictions and expirations counted so far.
+func (c *Cache[K, V]) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.store.stats
+}
+
+// Close stops the janitor. The cache remains usable, with expired entries
+// removed when they are looked up. Close is safe to call more than once.
+func (c *Cache[K, V]) Close() {
+	if c.janitor != nil {
+		c.janitor.stop()
+	}
+}
+
+// refresh loads a fresh value for the stale entry e of key in the background.
+// The value is only stored if e is still the entry of key, so that a Delete,
+// Clear or Set made while loading is not undone.
+func (c *Cache[K, V]) refresh(key K, e *entry[V], load func(key K) (V, error)) {
+	value, err := load(key)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e.refreshing = false
+	if err != nil || c.store.items[key] != e {
+		return
+	}
+	// key is cached, so nothing is evicted
+	c.store.set(key, &entry[V]{value: value}, c.store.opts.TTL, c.store.clock.Now())
+}
+
+// storeLoaded caches the result of load for key.
+func (c *Cache[K, V]) storeLoaded(key K, value V, err error) {
+	ttl := c.store.opts.TTL
+	if err != nil {
+		if c.store.opts.ErrorTTL <= 0 {
+			return
+		}
+		ttl = c.store.opts.ErrorTTL
+	}
+
+	c.mu.Lock()
+	evicted, evictedValue, ok := c.store.set(key, &entry[V]{value: value, err: err}, ttl, c.store.clock.Now())
+	c.mu.Unlock()
+
+	if ok && c.store.opts.OnEvict != nil {
+		c.store.opts.OnEvict(evicted, evictedValue)
+	}
+}
+
+// startJanitor starts a goroutine that removes expired entries every interval.
+// It only holds a weak pointer to the cache, so an unreachable cache is still
+// garbage collected, which stops the janitor.
+func (c *Cache[K, V]) startJanitor(interval time.Duration) {
+	stop := &janitorStop{ch: make(chan struct{})}
+	c.janitor = stop
+
+	go runJanitor(weak.Make(c), c.store.clock, interval, stop.ch)
+	runtime.AddCleanup(c, (*janitorStop).stop, stop)
+}
+
+// runJanitor calls DeleteExpired every interval until stop is closed or the cache is gone.
+func runJanitor[K comparable, V any](cache weak.Pointer[Cache[K, V]], clk clock.Clock, interval time.Duration, stop <-chan struct{}) {
+	timer := clk.NewTimer(interval)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-timer.C():
+		}
+
+		c := cache.Value()
+		if c == nil {
+			return
+		}
+		c.DeleteExpired()
+		timer.Reset(interval)
+	}
+}
+
+// stop stops the janitor, once.
+func (s *janitorStop) stop() {
+	s.once.Do(func() { close(s.ch) })
+}
+
+// newStore creates the state of a cache with the given options.
+func newStore[K comparable, V any](opts CacheOptions[K, V]) store[K, V] {
+	s := store[K, V]{
+		opts:  opts,
+		clock: clock.OrDefault(opts.Clock),
+	}
+	s.clear()
+
+	return s
+}
+
+// clear removes all entries and resets the eviction policy.
+func (s *store[K, V]) clear() {
+	s.items = make(map[K]*entry[V])
+	if s.opts.Capacity > 0 {
+		newPolicy := s.opts.Policy
+		if newPolicy == nil {
+			newPolicy = NewLRU[K]
+		}
+		s.policy = newPolicy(s.opts.Capacity)
+	}
+}
+
+// state reports whether e is fresh, stale or too old to serve at now.
+func (s *store[K, V]) state(e *entry[V], now time.Time) int {
+	switch {
+	case e.expires.IsZero() || now.Before(e.expires):
+		return fresh
+	case e.err == nil && now.Before(e.expires.Add(s.opts.StaleWhileRevalidate)):
+		return stale
+	default:
+		return missing
+	}
+}
+
+// lookup returns the entry for key and its state, removing it if it is too old to serve.
+func (s *store[K, V]) lookup(key K, now time.Time) (*entry[V], int) {
+	e, ok := s.items[key]
+	if !ok {
+		return nil, missing
+	}
+
+	state := s.state(e, now)
+	if state == missing {
+		s.remove(key)
+		s.stats.Expirations++
+	}
+
+	return e, state
+}
+
+// hit records a lookup that found the entry for key.
+func (s *store[K, V]) hit(key K) {
+	s.stats.Hits++
+	if s.policy != nil {
+		s.policy.Access(key)
+	}
+}
+
+// get returns the fresh value cached for key and records the lookup.
+func (s *store[K, V]) get(key K, now time.Time) (V, bool) {
+	e, state := s.lookup(key, now)
+	if state != fresh || e.err != nil {
+		s.stats.Misses++
+
+		var zero V
+
+		return zero, false
+	}
+
+	s.hit(key)
+
+	return e.value, true
+}
+
+// getOrLoad returns the value or error cached for key, or calls load and
+// caches its result. Stale entries are loaded again right away.
+func (s *store[K, V]) getOrLoad(key K, load func(key K) (V, error)) (V, error) {
+	if e, state := s.lookup(key, s.clock.Now()); state == fresh {
+		s.hit(key)
+
+		return e.value, e.err
+	}
+	s.stats.Misses++
+
+	value, err := load(key)
+
+	ttl := s.opts.TTL
+	if err != nil {
+		if s.opts.ErrorTTL <= 0 {
+			return value, err
+		}
+		ttl = s.opts.ErrorTTL
+	}
+
+	evicted, evictedValue, ok := s.set(key, &entry[V]{value: value, err: err}, ttl, s.clock.Now())
+	if ok && s.opts.OnEvict != nil {
+		s.opts.OnEvict(evicted, evictedValue)
+	}
+
+	return value, err
+}
+
+// set caches e for key for ttl and returns the entry evicted to make room, if
+// any. Cached errors are evicted without being returned.
+func (s *store[K, V]) set(key K, e *entry[V], ttl time.Duration, now time.Time) (K, V, bool) {
+	var zeroKey K
+	var zeroValue V
+
+	if ttl > 0 {
+		e.expires = now.Add(ttl)
+	}
+
+	if _, ok := s.items[key]; ok {
+		s.items[key] = e
+		if s.policy != nil {
+			s.policy.Access(key)
+		}
+
+		return zeroKey, zeroValue, false
+	}
+
+	s.items[key] = e
+	if s.policy == nil {
+		return zeroKey, zeroValue, false
+	}
+
+	victim, evict := s.policy.Insert(key)
+	if !evict {
+		return zeroKey, zeroValue, false
+	}
+
+	evicted, ok := s.items[victim]
+	if !ok {
+		return zeroKey, zeroValue, false
+	}
+	delete(s.items, victim)
+	s.stats.Evictions++
+
+	return victim, evicted.value, evicted.err == nil
+}
+
+// delete removes the entry for key. Returns true if there was one.
+func (s *store[K, V]) delete(key K) bool {
+	if _, ok := s.items[key]; !ok {
+		return false
+	}
+	s.remove(key)
+
+	return true
+}
+
+// remove removes the entry for key from the items and the policy.
+func (s *store[K, V]) remove(key K) {
+	delete(s.items, key)
+	if s.policy != nil {
+		s.policy.Remove(key)
+	}
+}
+
+// deleteExpired removes the entries too old to serve at now and returns how many were removed.
+func (s *store[K, V]) deleteExpired(now time.Time) int {
+	removed := 0
+	for key, e := range s.items {
+		if s.state(e, now) == missing {
+			s.remove(key)
+			removed++
+		}
+	}
+	s.stats.Expirations += uint64(removed)
+
+	return removed
+}
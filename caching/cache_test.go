@@ -0,0 +1,484 @@
+package caching
+
+import (
+	"errors"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kashifkhan0771/utils/clock"
+)
+
+func newFakeClock() *clock.Fake {
+	return clock.NewFake(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+}
+
+// eventually fails the test if cond is not true within a second.
+func eventually(t *testing.T, msg string, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal(msg)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestCache_GetSet(t *testing.T) {
+	c := NewCache(CacheOptions[string, int]{Capacity: 2})
+
+	if _, ok := c.Get("a"); ok {
+		t.Fatal("expected a miss on an empty cache")
+	}
+
+	c.Set("a", 1)
+	c.Set("b", 2)
+	if v, ok := c.Get("a"); !ok || v != 1 {
+		t.Fatalf("expected a=1, got %v, %v", v, ok)
+	}
+
+	c.Set("b", 20)
+	if v, _ := c.Peek("b"); v != 20 {
+		t.Errorf("expected Set to replace the value, got %v", v)
+	}
+	if c.Len() != 2 || c.Capacity() != 2 {
+		t.Errorf("expected Len 2 and Capacity 2, got %d and %d", c.Len(), c.Capacity())
+	}
+}
+
+func TestCache_Eviction(t *testing.T) {
+	var evicted []string
+	c := NewCache(CacheOptions[string, int]{
+		Capacity: 2,
+		OnEvict:  func(key string, _ int) { evicted = append(evicted, key) },
+	})
+
+	c.Set("a", 1)
+	c.Set("b", 2)
+	c.Get("a")
+	c.Set("c", 3)
+
+	if _, ok := c.Peek("b"); ok {
+		t.Error("expected the least recently used b to be evicted by default")
+	}
+	if len(evicted) != 1 || evicted[0] != "b" {
+		t.Errorf("expected OnEvict to be called for b, got %v", evicted)
+	}
+	if c.Len() != 2 {
+		t.Errorf("expected 2 entries, got %d", c.Len())
+	}
+}
+
+func TestCache_PeekDoesNotCountAsUse(t *testing.T) {
+	c := NewCache(CacheOptions[string, int]{Capacity: 2})
+	c.Set("a", 1)
+	c.Set("b", 2)
+	c.Peek("a")
+	c.Set("c", 3)
+
+	if _, ok := c.Peek("a"); ok {
+		t.Error("expected Peek not to protect a from eviction")
+	}
+	if stats := c.Stats(); stats.Hits != 0 || stats.Misses != 0 {
+		t.Errorf("expected Peek not to update the stats, got %+v", stats)
+	}
+}
+
+func TestCache_Policy(t *testing.T) {
+	c := NewCache(CacheOptions[string, int]{Capacity: 2, Policy: NewLFU})
+	c.Set("a", 1)
+	c.Set("b", 2)
+	c.Get("a")
+	c.Get("a")
+	c.Get("b")
+	c.Set("c", 3)
+
+	if _, ok := c.Peek("b"); ok {
+		t.Error("expected the least frequently used b to be evicted")
+	}
+}
+
+func TestCache_DeleteAndClear(t *testing.T) {
+	c := NewCache(CacheOptions[int, int]{Capacity: 2})
+	c.Set(1, 1)
+	c.Set(2, 2)
+
+	if !c.Delete(1) || c.Delete(1) {
+		t.Fatal("expected Delete to report whether the key was cached")
+	}
+	c.Set(3, 3)
+	if _, ok := c.Peek(2); !ok {
+		t.Fatal("expected a deleted entry to free its slot")
+	}
+
+	c.Get(2)
+	c.Clear()
+	if c.Len() != 0 {
+		t.Errorf("expected an empty cache after Clear, got %d entries", c.Len())
+	}
+	if c.Stats().Hits != 1 {
+		t.Errorf("expected Clear to keep the stats, got %+v", c.Stats())
+	}
+	c.Set(4, 4)
+	c.Set(5, 5)
+	c.Set(6, 6)
+	if c.Len() != 2 {
+		t.Errorf("expected the capacity to still apply after Clear, got %d entries", c.Len())
+	}
+}
+
+func TestCache_Unbounded(t *testing.T) {
+	c := NewCache(CacheOptions[int, int]{})
+	for i := range 1000 {
+		c.Set(i, i)
+	}
+
+	if c.Len() != 1000 || c.Capacity() != 0 {
+		t.Errorf("expected 1000 entries in an unbounded cache, got %d (capacity %d)", c.Len(), c.Capacity())
+	}
+	if c.Stats().Evictions != 0 {
+		t.Errorf("expected no evictions, got %d", c.Stats().Evictions)
+	}
+}
+
+func TestCache_Stats(t *testing.T) {
+	c := NewCache(CacheOptions[int, int]{Capacity: 1})
+	c.Set(1, 1)
+	c.Get(1)
+	c.Get(2)
+	c.Set(2, 2)
+
+	want := CacheStats{Hits: 1, Misses: 1, Evictions: 1}
+	if stats := c.Stats(); stats != want {
+		t.Errorf("expected %+v, got %+v", want, stats)
+	}
+}
+
+func TestCache_Concurrent(t *testing.T) {
+	for _, policy := range []func(int) Policy[int]{NewLRU[int], NewLFU[int], NewARC[int]} {
+		c := NewCache(CacheOptions[int, int]{Capacity: 16, Policy: policy})
+
+		var wg sync.WaitGroup
+		for g := range 8 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				for i := range 1000 {
+					key := (g*7 + i) % 64
+					if v, ok := c.Get(key); ok && v != key {
+						t.Errorf("expected %d, got %d", key, v)
+					}
+					c.Set(key, key)
+					if i%10 == 0 {
+						c.Delete(key)
+					}
+				}
+			}()
+		}
+		wg.Wait()
+
+		if c.Len() > 16 {
+			t.Errorf("expected at most 16 entries, got %d", c.Len())
+		}
+	}
+}
+
+func TestCache_TTL(t *testing.T) {
+	clk := newFakeClock()
+	c := NewCache(CacheOptions[string, int]{TTL: time.Minute, Clock: clk})
+	c.Set("a", 1)
+
+	clk.Advance(59 * time.Second)
+	if v, ok := c.Get("a"); !ok || v != 1 {
+		t.Fatalf("expected a fresh entry before the TTL, got %v, %v", v, ok)
+	}
+
+	clk.Advance(time.Second)
+	if _, ok := c.Peek("a"); ok {
+		t.Fatal("expected Peek to skip an expired entry")
+	}
+	if c.Len() != 1 {
+		t.Fatalf("expected the expired entry to stay until it is looked up, got %d entries", c.Len())
+	}
+	if _, ok := c.Get("a"); ok {
+		t.Fatal("expected the entry to expire after the TTL")
+	}
+	if c.Len() != 0 || c.Stats().Expirations != 1 {
+		t.Errorf("expected Get to remove the expired entry, got %d entries and %+v", c.Len(), c.Stats())
+	}
+}
+
+func TestCache_SetWithTTL(t *testing.T) {
+	clk := newFakeClock()
+	c := NewCache(CacheOptions[string, int]{TTL: time.Minute, Clock: clk})
+	c.SetWithTTL("short", 1, time.Second)
+	c.SetWithTTL("forever", 2, 0)
+	c.Set("default", 3)
+
+	clk.Advance(time.Second)
+	if _, ok := c.Get("short"); ok {
+		t.Error("expected the entry to expire after its own TTL")
+	}
+	if _, ok := c.Get("default"); !ok {
+		t.Error("expected the entry to use the default TTL")
+	}
+
+	clk.Advance(time.Hour)
+	if _, ok := c.Get("forever"); !ok {
+		t.Error("expected an entry without TTL never to expire")
+	}
+}
+
+func TestCache_DeleteExpired(t *testing.T) {
+	clk := newFakeClock()
+	c := NewCache(CacheOptions[int, int]{Capacity: 10, TTL: time.Minute, Clock: clk})
+	for i := range 5 {
+		c.Set(i, i)
+	}
+	clk.Advance(30 * time.Second)
+	c.Set(5, 5)
+
+	clk.Advance(30 * time.Second)
+	if removed := c.DeleteExpired(); removed != 5 {
+		t.Fatalf("expected 5 expired entries to be removed, got %d", removed)
+	}
+	if c.Len() != 1 || c.Stats().Expirations != 5 {
+		t.Errorf("expected 1 entry and 5 expirations, got %d and %+v", c.Len(), c.Stats())
+	}
+
+	// Removed entries free their slots in the policy
+	for i := 10; i < 19; i++ {
+		c.Set(i, i)
+	}
+	if _, ok := c.Get(5); !ok || c.Stats().Evictions != 0 {
+		t.Errorf("expected no evictions below capacity, got %+v", c.Stats())
+	}
+}
+
+func TestCache_Janitor(t *testing.T) {
+	clk := newFakeClock()
+	c := NewCache(CacheOptions[string, int]{TTL: 30 * time.Second, CleanupInterval: time.Minute, Clock: clk})
+	c.Set("a", 1)
+
+	clk.WaitForTimers(1)
+	clk.Advance(time.Minute)
+	eventually(t, "expected the janitor to remove the expired entry", func() bool { return c.Len() == 0 })
+
+	c.Close()
+	c.Close()
+	eventually(t, "expected Close to stop the janitor", func() bool { return clk.Timers() == 0 })
+}
+
+func TestCache_JanitorStopsWhenCollected(t *testing.T) {
+	clk := newFakeClock()
+	func() {
+		c := NewCache(CacheOptions[string, int]{TTL: time.Second, CleanupInterval: time.Minute, Clock: clk})
+		c.Set("a", 1)
+	}()
+	clk.WaitForTimers(1)
+
+	eventually(t, "expected the janitor to stop once the cache is collected", func() bool {
+		runtime.GC()
+
+		return clk.Timers() == 0
+	})
+}
+
+func TestCache_GetOrLoad(t *testing.T) {
+	clk := newFakeClock()
+	c := NewCache(CacheOptions[string, int]{TTL: time.Minute, Clock: clk})
+
+	calls := 0
+	load := func(key string) (int, error) {
+		calls++
+
+		return len(key), nil
+	}
+
+	for range 3 {
+		if v, err := c.GetOrLoad("abc", load); v != 3 || err != nil {
+			t.Fatalf("expected 3, got %v, %v", v, err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("expected a single load, got %d", calls)
+	}
+
+	clk.Advance(time.Minute)
+	c.GetOrLoad("abc", load)
+	if calls != 2 {
+		t.Errorf("expected an expired entry to be loaded again, got %d loads", calls)
+	}
+}
+
+func TestCache_GetOrLoadErrors(t *testing.T) {
+	errBoom := errors.New("boom")
+	clk := newFakeClock()
+
+	calls := 0
+	load := func(string) (int, error) {
+		calls++
+
+		return 0, errBoom
+	}
+
+	// Errors are not cached by default
+	c := NewCache(CacheOptions[string, int]{TTL: time.Hour, Clock: clk})
+	c.GetOrLoad("a", load)
+	if _, err := c.GetOrLoad("a", load); !errors.Is(err, errBoom) || calls != 2 {
+		t.Fatalf("expected the error to be loaded again, got %v after %d loads", err, calls)
+	}
+
+	calls = 0
+	c = NewCache(CacheOptions[string, int]{TTL: time.Hour, ErrorTTL: time.Second, Clock: clk})
+	c.GetOrLoad("a", load)
+	if _, err := c.GetOrLoad("a", load); !errors.Is(err, errBoom) || calls != 1 {
+		t.Fatalf("expected the cached error without a load, got %v after %d loads", err, calls)
+	}
+	if _, ok := c.Get("a"); ok {
+		t.Error("expected Get not to return a cached error")
+	}
+
+	clk.Advance(time.Second)
+	c.GetOrLoad("a", load)
+	if calls != 2 {
+		t.Errorf("expected the error to be loaded again after ErrorTTL, got %d loads", calls)
+	}
+}
+
+func TestCache_StaleWhileRevalidate(t *testing.T) {
+	clk := newFakeClock()
+	c := NewCache(CacheOptions[string, int]{TTL: time.Minute, StaleWhileRevalidate: time.Minute, Clock: clk})
+	c.Set("a", 1)
+
+	var calls atomic.Int32
+	release := make(chan struct{})
+	load := func(string) (int, error) {
+		calls.Add(1)
+		<-release
+
+		return 2, nil
+	}
+
+	clk.Advance(time.Minute)
+	if _, ok := c.Get("a"); ok {
+		t.Error("expected Get not to return a stale entry")
+	}
+
+	// Stale values are served while a single goroutine refreshes
+	for range 5 {
+		if v, err := c.GetOrLoad("a", load); v != 1 || err != nil {
+			t.Fatalf("expected the stale value 1, got %v, %v", v, err)
+		}
+	}
+	close(release)
+
+	eventually(t, "expected the refreshed value", func() bool {
+		v, ok := c.Get("a")
+
+		return ok && v == 2
+	})
+	if calls.Load() != 1 {
+		t.Errorf("expected a single refresh, got %d", calls.Load())
+	}
+
+	// Past the stale window, the value is loaded before being served
+	clk.Advance(2 * time.Minute)
+	if v, _ := c.GetOrLoad("a", func(string) (int, error) { return 3, nil }); v != 3 {
+		t.Errorf("expected a synchronous load past the stale window, got %v", v)
+	}
+}
+
+func TestCache_RefreshDoesNotUndoChanges(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(c *Cache[string, int])
+		want   int // Value of "a" after the refresh, 0 if absent
+	}{
+		{name: "delete", change: func(c *Cache[string, int]) { c.Delete("a") }},
+		{name: "clear", change: func(c *Cache[string, int]) { c.Clear() }},
+		{name: "set", change: func(c *Cache[string, int]) { c.Set("a", 5) }, want: 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clk := newFakeClock()
+			c := NewCache(CacheOptions[string, int]{TTL: time.Minute, StaleWhileRevalidate: time.Minute, Clock: clk})
+			c.Set("a", 1)
+			clk.Advance(time.Minute)
+
+			c.mu.Lock()
+			e := c.store.items["a"]
+			c.mu.Unlock()
+
+			started, release := make(chan struct{}), make(chan struct{})
+			c.GetOrLoad("a", func(string) (int, error) {
+				close(started)
+				<-release
+
+				return 2, nil
+			})
+			<-started
+			tt.change(c)
+			close(release)
+
+			eventually(t, "expected the refresh to finish", func() bool {
+				c.mu.Lock()
+				defer c.mu.Unlock()
+
+				return !e.refreshing
+			})
+			if v, _ := c.Get("a"); v != tt.want {
+				t.Errorf("expected %d after the refresh, got %d", tt.want, v)
+			}
+		})
+	}
+}
+
+func TestCache_FailedRefreshKeepsStaleValue(t *testing.T) {
+	clk := newFakeClock()
+	c := NewCache(CacheOptions[string, int]{TTL: time.Minute, StaleWhileRevalidate: time.Minute, Clock: clk})
+	c.Set("a", 1)
+	clk.Advance(time.Minute)
+
+	var calls atomic.Int32
+	failing := func(string) (int, error) {
+		calls.Add(1)
+
+		return 0, errors.New("boom")
+	}
+
+	if v, err := c.GetOrLoad("a", failing); v != 1 || err != nil {
+		t.Fatalf("expected the stale value 1, got %v, %v", v, err)
+	}
+	eventually(t, "expected the refresh to run", func() bool { return calls.Load() == 1 })
+
+	// The next call tries again
+	eventually(t, "expected another refresh after the failure", func() bool {
+		v, err := c.GetOrLoad("a", failing)
+		if v != 1 || err != nil {
+			t.Fatalf("expected the stale value 1, got %v, %v", v, err)
+		}
+
+		return calls.Load() >= 2
+	})
+}
+
+func BenchmarkCache_Set(b *testing.B) {
+	for name, policy := range map[string]func(int) Policy[int]{"lru": NewLRU[int], "lfu": NewLFU[int], "arc": NewARC[int]} {
+		b.Run(name, func(b *testing.B) {
+			c := NewCache(CacheOptions[int, int]{Capacity: 1024, Policy: policy})
+			b.ReportAllocs()
+			i := 0
+			for b.Loop() {
+				c.Set(i%4096, i)
+				i++
+			}
+		})
+	}
+}
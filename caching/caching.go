@@ -1,6 +1,7 @@
 // Package caching provides utilities for creating caching decorators to
 // enhance the performance of functions by storing computed results.
-// It includes both thread-safe and non-thread-safe implementations.
+// It includes both thread-safe and non-thread-safe implementations, and a
+// generic Cache that can be bounded with an eviction policy and expire entries.
 
 package caching
 
@@ -39,3 +40,59 @@ func SafeCacheWrapper[T comparable, R any](fn func(T) R) func(T) R {
 		return result
 	}
 }
+
+// CacheWrapperWithOptions is a non-thread-safe caching decorator configured
+// with opts. It keeps at most opts.Capacity results, evicting the one chosen by
+// opts.Policy when full, and computes results again once they are older than opts.TTL.
+// StaleWhileRevalidate and CleanupInterval need SafeCacheWrapperWithOptions and are ignored.
+func CacheWrapperWithOptions[T comparable, R any](fn func(T) R, opts CacheOptions[T, R]) func(T) R {
+	cache := newStore(opts)
+	load := func(input T) (R, error) { return fn(input), nil }
+
+	return func(input T) R {
+		result, _ := cache.getOrLoad(input, load)
+
+		return result
+	}
+}
+
+// SafeCacheWrapperWithOptions is a thread-safe caching decorator configured
+// with opts. It keeps at most opts.Capacity results, evicting the one chosen by
+// opts.Policy when full, and expires them after opts.TTL. Results within
+// opts.StaleWhileRevalidate of expiring are returned while fn refreshes them in the background.
+// Concurrent calls with the same uncached input may each call fn.
+func SafeCacheWrapperWithOptions[T comparable, R any](fn func(T) R, opts CacheOptions[T, R]) func(T) R {
+	cache := NewCache(opts)
+	load := func(input T) (R, error) { return fn(input), nil }
+
+	return func(input T) R {
+		result, _ := cache.GetOrLoad(input, load)
+
+		return result
+	}
+}
+
+// CacheWrapperErr is a non-thread-safe caching decorator for functions that
+// can fail, configured with opts like CacheWrapperWithOptions.
+// Errors are not cached unless opts.ErrorTTL is set, in which case the error
+// is returned for that long without calling fn again.
+func CacheWrapperErr[T comparable, R any](fn func(T) (R, error), opts CacheOptions[T, R]) func(T) (R, error) {
+	cache := newStore(opts)
+
+	return func(input T) (R, error) {
+		return cache.getOrLoad(input, fn)
+	}
+}
+
+// SafeCacheWrapperErr is a thread-safe caching decorator for functions that
+// can fail, configured with opts like SafeCacheWrapperWithOptions.
+// Errors are not cached unless opts.ErrorTTL is set, in which case the error
+// is returned for that long without calling fn again. A failed background
+// refresh keeps the stale result.
+func SafeCacheWrapperErr[T comparable, R any](fn func(T) (R, error), opts CacheOptions[T, R]) func(T) (R, error) {
+	cache := NewCache(opts)
+
+	return func(input T) (R, error) {
+		return cache.GetOrLoad(input, fn)
+	}
+}
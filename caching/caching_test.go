@@ -1,9 +1,16 @@
 package caching
 
 import (
+	"errors"
+	"fmt"
+	"math"
 	"math/big"
 	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
+
+	"github.com/kashifkhan0771/utils/clock"
 )
 
 type testCase[T any] struct {
@@ -120,6 +127,158 @@ func TestSafeCacheWrapperConcurrency(t *testing.T) {
 	}
 }
 
+// TestCacheWrapperWithOptions tests the bounded non-thread-safe caching wrapper.
+func TestCacheWrapperWithOptions(t *testing.T) {
+	calls := 0
+	double := func(n int) int {
+		calls++
+
+		return n * 2
+	}
+
+	var evicted []int
+	cachedDouble := CacheWrapperWithOptions(double, CacheOptions[int, int]{
+		Capacity: 2,
+		OnEvict:  func(key, _ int) { evicted = append(evicted, key) },
+	})
+
+	for _, input := range []int{1, 2, 1, 3, 1, 2} {
+		if got := cachedDouble(input); got != input*2 {
+			t.Errorf("CacheWrapperWithOptions() = %v, want %v", got, input*2)
+		}
+	}
+
+	// 1 stays cached while 2 and 3 evict each other
+	if calls != 4 {
+		t.Errorf("expected 4 calls, got %d", calls)
+	}
+	if len(evicted) != 2 || evicted[0] != 2 || evicted[1] != 3 {
+		t.Errorf("expected 2 and 3 to be evicted, got %v", evicted)
+	}
+}
+
+// TestSafeCacheWrapperWithOptions tests the bounded thread-safe caching wrapper.
+func TestSafeCacheWrapperWithOptions(t *testing.T) {
+	var calls atomic.Int32
+	square := func(n int) int {
+		calls.Add(1)
+
+		return n * n
+	}
+
+	cachedSquare := SafeCacheWrapperWithOptions(square, CacheOptions[int, int]{Capacity: 4, Policy: NewARC})
+
+	var wg sync.WaitGroup
+	for i := range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for j := range 100 {
+				input := (i + j) % 8
+				if got := cachedSquare(input); got != input*input {
+					t.Errorf("SafeCacheWrapperWithOptions() = %v, want %v", got, input*input)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if calls.Load() < 8 {
+		t.Errorf("expected every input to be computed at least once, got %d calls", calls.Load())
+	}
+}
+
+// TestCacheWrapperWithOptionsTTL tests that results expire after the TTL.
+func TestCacheWrapperWithOptionsTTL(t *testing.T) {
+	clk := clock.NewFake(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	calls := 0
+	double := func(n int) int {
+		calls++
+
+		return n * 2
+	}
+
+	cachedDouble := CacheWrapperWithOptions(double, CacheOptions[int, int]{TTL: time.Minute, Clock: clk})
+	cachedDouble(1)
+	cachedDouble(1)
+	if calls != 1 {
+		t.Fatalf("expected 1 call before the TTL, got %d", calls)
+	}
+
+	clk.Advance(time.Minute)
+	if got := cachedDouble(1); got != 2 || calls != 2 {
+		t.Errorf("expected the result to be computed again after the TTL, got %v after %d calls", got, calls)
+	}
+}
+
+// TestCacheWrapperErr tests the non-thread-safe caching wrapper for functions that can fail.
+func TestCacheWrapperErr(t *testing.T) {
+	clk := clock.NewFake(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	errNegative := errors.New("negative input")
+	calls := 0
+	sqrt := func(n int) (int, error) {
+		calls++
+		if n < 0 {
+			return 0, errNegative
+		}
+
+		return int(math.Sqrt(float64(n))), nil
+	}
+
+	cachedSqrt := CacheWrapperErr(sqrt, CacheOptions[int, int]{ErrorTTL: time.Second, Clock: clk})
+
+	if got, err := cachedSqrt(16); got != 4 || err != nil {
+		t.Fatalf("CacheWrapperErr() = %v, %v, want 4, nil", got, err)
+	}
+	for range 2 {
+		if _, err := cachedSqrt(-1); !errors.Is(err, errNegative) {
+			t.Fatalf("CacheWrapperErr() error = %v, want %v", err, errNegative)
+		}
+	}
+	cachedSqrt(16)
+	if calls != 2 {
+		t.Errorf("expected the result and the error to be cached, got %d calls", calls)
+	}
+
+	clk.Advance(time.Second)
+	cachedSqrt(-1)
+	if calls != 3 {
+		t.Errorf("expected the error to expire after ErrorTTL, got %d calls", calls)
+	}
+}
+
+// TestSafeCacheWrapperErr tests the thread-safe caching wrapper for functions that can fail.
+func TestSafeCacheWrapperErr(t *testing.T) {
+	clk := clock.NewFake(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	var version atomic.Int32
+	fetch := func(key string) (string, error) {
+		return fmt.Sprintf("%s-v%d", key, version.Add(1)), nil
+	}
+
+	cachedFetch := SafeCacheWrapperErr(fetch, CacheOptions[string, string]{
+		TTL:                  time.Minute,
+		StaleWhileRevalidate: time.Minute,
+		Clock:                clk,
+	})
+
+	if got, _ := cachedFetch("config"); got != "config-v1" {
+		t.Fatalf("SafeCacheWrapperErr() = %v, want config-v1", got)
+	}
+
+	// The stale result is returned while it is refreshed in the background
+	clk.Advance(time.Minute)
+	if got, _ := cachedFetch("config"); got != "config-v1" {
+		t.Fatalf("SafeCacheWrapperErr() = %v, want the stale config-v1", got)
+	}
+
+	eventually(t, "expected the refreshed config-v2", func() bool {
+		got, _ := cachedFetch("config")
+
+		return got == "config-v2"
+	})
+}
+
 // ================================================================================
 // ### BENCHMARKS
 // ================================================================================
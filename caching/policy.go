@@ -0,0 +1,292 @@
+package caching
+
+import "container/list"
+
+// Policy decides which key a bounded Cache evicts when it is full.
+// A Cache serializes its calls, so implementations need not be safe for concurrent use.
+type Policy[K comparable] interface {
+	// Access records a read or an update of a cached key.
+	Access(key K)
+	// Insert records a key added to the cache, which is not cached yet, and
+	// returns the key to evict if the cache is now over capacity.
+	Insert(key K) (victim K, evict bool)
+	// Remove forgets a key deleted from the cache.
+	Remove(key K)
+}
+
+// lruPolicy evicts the least recently used key.
+type lruPolicy[K comparable] struct {
+	capacity int
+	order    list.List // Keys, most recently used first
+	elems    map[K]*list.Element
+}
+
+// NewLRU returns a Policy that evicts the least recently used key.
+// If capacity < 1, it defaults to 1.
+func NewLRU[K comparable](capacity int) Policy[K] {
+	return &lruPolicy[K]{
+		capacity: max(capacity, 1),
+		elems:    make(map[K]*list.Element),
+	}
+}
+
+func (p *lruPolicy[K]) Access(key K) {
+	if elem, ok := p.elems[key]; ok {
+		p.order.MoveToFront(elem)
+	}
+}
+
+func (p *lruPolicy[K]) Insert(key K) (K, bool) {
+	p.elems[key] = p.order.PushFront(key)
+	if p.order.Len() <= p.capacity {
+		var zero K
+
+		return zero, false
+	}
+
+	victim, _ := p.order.Remove(p.order.Back()).(K)
+	delete(p.elems, victim)
+
+	return victim, true
+}
+
+func (p *lruPolicy[K]) Remove(key K) {
+	if elem, ok := p.elems[key]; ok {
+		p.order.Remove(elem)
+		delete(p.elems, key)
+	}
+}
+
+// lfuPolicy evicts the least frequently used key, and the least recently used
+// one among keys used equally often. All operations are O(1).
+type lfuPolicy[K comparable] struct {
+	capacity int
+	buckets  list.List // *lfuBucket, by increasing frequency
+	entries  map[K]*lfuEntry[K]
+}
+
+// lfuBucket holds the keys used freq times.
+type lfuBucket[K comparable] struct {
+	freq int
+	keys list.List // Keys, most recently used first
+}
+
+// lfuEntry locates a key in its bucket.
+type lfuEntry[K comparable] struct {
+	bucket *list.Element // Element of lfuPolicy.buckets
+	elem   *list.Element // Element of lfuBucket.keys
+}
+
+// NewLFU returns a Policy that evicts the least frequently used key, and the
+// least recently used one among keys used equally often.
+// If capacity < 1, it defaults to 1.
+func NewLFU[K comparable](capacity int) Policy[K] {
+	return &lfuPolicy[K]{
+		capacity: max(capacity, 1),
+		entries:  make(map[K]*lfuEntry[K]),
+	}
+}
+
+func (p *lfuPolicy[K]) Access(key K) {
+	entry, ok := p.entries[key]
+	if !ok {
+		return
+	}
+
+	bucket := bucketOf[K](entry.bucket)
+	next := entry.bucket.Next()
+	if next == nil || bucketOf[K](next).freq != bucket.freq+1 {
+		next = p.buckets.InsertAfter(&lfuBucket[K]{freq: bucket.freq + 1}, entry.bucket)
+	}
+
+	p.unlink(entry)
+	entry.bucket = next
+	entry.elem = bucketOf[K](next).keys.PushFront(key)
+}
+
+func (p *lfuPolicy[K]) Insert(key K) (K, bool) {
+	var victim K
+	evict := false
+
+	// Evict before adding, or the new key would be the least frequently used
+	if len(p.entries) >= p.capacity {
+		if front := p.buckets.Front(); front != nil {
+			keys := &bucketOf[K](front).keys
+			victim, _ = keys.Back().Value.(K)
+			p.unlink(p.entries[victim])
+			delete(p.entries, victim)
+			evict = true
+		}
+	}
+
+	front := p.buckets.Front()
+	if front == nil || bucketOf[K](front).freq != 1 {
+		front = p.buckets.PushFront(&lfuBucket[K]{freq: 1})
+	}
+	p.entries[key] = &lfuEntry[K]{bucket: front, elem: bucketOf[K](front).keys.PushFront(key)}
+
+	return victim, evict
+}
+
+func (p *lfuPolicy[K]) Remove(key K) {
+	if entry, ok := p.entries[key]; ok {
+		p.unlink(entry)
+		delete(p.entries, key)
+	}
+}
+
+// unlink removes the key of entry from its bucket, and drops the bucket if it is left empty.
+func (p *lfuPolicy[K]) unlink(entry *lfuEntry[K]) {
+	bucket := bucketOf[K](entry.bucket)
+	bucket.keys.Remove(entry.elem)
+	if bucket.keys.Len() == 0 {
+		p.buckets.Remove(entry.bucket)
+	}
+}
+
+// bucketOf returns the bucket held by an element of lfuPolicy.buckets.
+func bucketOf[K comparable](elem *list.Element) *lfuBucket[K] {
+	bucket, _ := elem.Value.(*lfuBucket[K])
+
+	return bucket
+}
+
+// ARC lists. Keys in t1 and t2 are cached; b1 and b2 remember keys recently
+// evicted from t1 and t2.
+const (
+	arcT1 = iota // Cached keys used once recently
+	arcT2        // Cached keys used at least twice recently
+	arcB1        // Ghosts of keys evicted from t1
+	arcB2        // Ghosts of keys evicted from t2
+)
+
+// arcPolicy implements the Adaptive Replacement Cache of Megiddo and Modha.
+// It balances recency (t1) against frequency (t2), and learns from hits on
+// the ghost lists which of the two the workload rewards.
+type arcPolicy[K comparable] struct {
+	capacity int
+	target   int          // Target size of t1
+	lists    [4]list.List // Keys, most recently used first
+	entries  map[K]*arcEntry
+}
+
+// arcEntry locates a key in one of the ARC lists.
+type arcEntry struct {
+	list int
+	elem *list.Element
+}
+
+// NewARC returns a Policy implementing the Adaptive Replacement Cache, which
+// adapts between evicting by recency and by frequency, and resists scans of
+// keys used only once. It remembers up to capacity recently evicted keys.
+// If capacity < 1, it defaults to 1.
+func NewARC[K comparable](capacity int) Policy[K] {
+	return &arcPolicy[K]{
+		capacity: max(capacity, 1),
+		entries:  make(map[K]*arcEntry),
+	}
+}
+
+func (p *arcPolicy[K]) Access(key K) {
+	if entry, ok := p.entries[key]; ok && (entry.list == arcT1 || entry.list == arcT2) {
+		p.move(key, entry, arcT2)
+	}
+}
+
+func (p *arcPolicy[K]) Insert(key K) (K, bool) {
+	var victim K
+	evict := false
+	c := p.capacity
+	t1, t2 := p.lists[arcT1].Len(), p.lists[arcT2].Len()
+	b1, b2 := p.lists[arcB1].Len(), p.lists[arcB2].Len()
+	full := t1+t2 >= c
+
+	if entry, ok := p.entries[key]; ok {
+		// A ghost hit: the list it was evicted from should have been larger
+		inB2 := entry.list == arcB2
+		if inB2 {
+			p.target = max(p.target-max(b1/b2, 1), 0)
+		} else {
+			p.target = min(p.target+max(b2/b1, 1), c)
+		}
+		if full {
+			victim, evict = p.replace(inB2)
+		}
+		p.move(key, entry, arcT2)
+
+		return victim, evict
+	}
+
+	switch {
+	case t1+b1 >= c:
+		if t1 < c {
+			p.dropLRU(arcB1)
+			if full {
+				victim, evict = p.replace(false)
+			}
+		} else {
+			victim, evict = p.dropLRU(arcT1), true
+		}
+	case t1+t2+b1+b2 >= c:
+		if t1+t2+b1+b2 >= 2*c {
+			p.dropLRU(arcB2)
+		}
+		if full {
+			victim, evict = p.replace(false)
+		}
+	}
+
+	p.entries[key] = &arcEntry{list: arcT1, elem: p.lists[arcT1].PushFront(key)}
+
+	return victim, evict
+}
+
+func (p *arcPolicy[K]) Remove(key K) {
+	if entry, ok := p.entries[key]; ok {
+		p.lists[entry.list].Remove(entry.elem)
+		delete(p.entries, key)
+	}
+}
+
+// replace evicts the least recently used key of t1 or t2, depending on the
+// target size of t1, and remembers it in the matching ghost list.
+func (p *arcPolicy[K]) replace(inB2 bool) (K, bool) {
+	t1 := p.lists[arcT1].Len()
+
+	from, to := arcT2, arcB2
+	if t1 > 0 && (t1 > p.target || (inB2 && t1 == p.target)) || p.lists[arcT2].Len() == 0 {
+		from, to = arcT1, arcB1
+	}
+
+	back := p.lists[from].Back()
+	if back == nil {
+		var zero K
+
+		return zero, false
+	}
+	key, _ := back.Value.(K)
+	p.move(key, p.entries[key], to)
+
+	return key, true
+}
+
+// move makes key the most recently used of list l.
+func (p *arcPolicy[K]) move(key K, entry *arcEntry, l int) {
+	p.lists[entry.list].Remove(entry.elem)
+	entry.list = l
+	entry.elem = p.lists[l].PushFront(key)
+}
+
+// dropLRU forgets the least recently used key of list l and returns it.
+func (p *arcPolicy[K]) dropLRU(l int) K {
+	var key K
+
+	back := p.lists[l].Back()
+	if back == nil {
+		return key
+	}
+	key, _ = p.lists[l].Remove(back).(K)
+	delete(p.entries, key)
+
+	return key
+}
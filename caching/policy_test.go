@@ -0,0 +1,169 @@
+package caching
+
+import (
+	"math/rand/v2"
+	"testing"
+)
+
+// insertAll inserts keys into p and returns the keys it evicted, in order.
+func insertAll(p Policy[int], keys ...int) []int {
+	var evicted []int
+	for _, key := range keys {
+		if victim, ok := p.Insert(key); ok {
+			evicted = append(evicted, victim)
+		}
+	}
+
+	return evicted
+}
+
+func equalInts(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func TestLRU(t *testing.T) {
+	p := NewLRU[int](3)
+
+	if evicted := insertAll(p, 1, 2, 3); len(evicted) != 0 {
+		t.Fatalf("expected no evictions below capacity, got %v", evicted)
+	}
+
+	p.Access(1)
+	if evicted := insertAll(p, 4, 5); !equalInts(evicted, []int{2, 3}) {
+		t.Errorf("expected 2 and 3 to be evicted, got %v", evicted)
+	}
+
+	p.Remove(1)
+	if evicted := insertAll(p, 6, 7); !equalInts(evicted, []int{4}) {
+		t.Errorf("expected only 4 to be evicted after removing 1, got %v", evicted)
+	}
+}
+
+func TestLFU(t *testing.T) {
+	p := NewLFU[int](3)
+	insertAll(p, 1, 2, 3)
+
+	p.Access(1)
+	p.Access(1)
+	p.Access(2)
+	if evicted := insertAll(p, 4); !equalInts(evicted, []int{3}) {
+		t.Fatalf("expected the least frequently used 3 to be evicted, got %v", evicted)
+	}
+
+	// 4 is the only key used once
+	if evicted := insertAll(p, 5); !equalInts(evicted, []int{4}) {
+		t.Fatalf("expected 4 to be evicted, got %v", evicted)
+	}
+
+	// 2 and 5 are now both used twice; 2 was used less recently
+	p.Access(5)
+	p.Remove(1)
+	if evicted := insertAll(p, 6, 7); !equalInts(evicted, []int{6}) {
+		t.Errorf("expected the new key 6 to be evicted first, got %v", evicted)
+	}
+	p.Access(7)
+	if evicted := insertAll(p, 8); !equalInts(evicted, []int{2}) {
+		t.Errorf("expected the least recently used 2 among equally used keys, got %v", evicted)
+	}
+}
+
+func TestARC_ScanResistance(t *testing.T) {
+	p := NewARC[int](4)
+	insertAll(p, 1, 2)
+	p.Access(1)
+	p.Access(2)
+
+	// A scan of keys used once only evicts other keys used once
+	evicted := insertAll(p, 100, 101, 102, 103, 104, 105)
+	for _, key := range evicted {
+		if key == 1 || key == 2 {
+			t.Fatalf("expected frequently used keys to survive the scan, evicted %v", evicted)
+		}
+	}
+}
+
+func TestARC_GhostHitAdapts(t *testing.T) {
+	p := NewARC[int](2)
+	arc, _ := p.(*arcPolicy[int])
+
+	insertAll(p, 1, 2)
+	p.Access(1)
+	if evicted := insertAll(p, 3); !equalInts(evicted, []int{2}) {
+		t.Fatalf("expected 2 to be evicted from t1, got %v", evicted)
+	}
+	if arc.entries[2] == nil || arc.entries[2].list != arcB1 {
+		t.Fatal("expected 2 to be remembered in b1")
+	}
+
+	// Reinserting 2 is a ghost hit: t1 should have been larger
+	insertAll(p, 2)
+	if arc.target != 1 {
+		t.Errorf("expected the target size of t1 to grow to 1, got %d", arc.target)
+	}
+	if arc.entries[2].list != arcT2 {
+		t.Error("expected a ghost hit to cache the key in t2")
+	}
+}
+
+func TestPolicies_Consistency(t *testing.T) {
+	policies := map[string]func(int) Policy[int]{
+		"lru": NewLRU[int],
+		"lfu": NewLFU[int],
+		"arc": NewARC[int],
+	}
+
+	for name, newPolicy := range policies {
+		t.Run(name, func(t *testing.T) {
+			const capacity = 8
+			p := newPolicy(capacity)
+			cached := make(map[int]bool)
+			rng := rand.New(rand.NewPCG(1, 2)) //nolint:gosec
+
+			for range 10_000 {
+				key := rng.IntN(32)
+				switch op := rng.IntN(10); {
+				case op == 0:
+					if cached[key] {
+						p.Remove(key)
+						delete(cached, key)
+					}
+				case cached[key]:
+					p.Access(key)
+				default:
+					cached[key] = true
+					if victim, ok := p.Insert(key); ok {
+						if !cached[victim] {
+							t.Fatalf("evicted %d, which is not cached", victim)
+						}
+						delete(cached, victim)
+					}
+				}
+
+				if len(cached) > capacity {
+					t.Fatalf("expected at most %d keys, got %d", capacity, len(cached))
+				}
+			}
+
+			if arc, ok := p.(*arcPolicy[int]); ok && len(arc.entries) > 2*capacity {
+				t.Errorf("expected ARC to remember at most %d keys, got %d", 2*capacity, len(arc.entries))
+			}
+		})
+	}
+}
+
+func TestPolicies_DefaultCapacity(t *testing.T) {
+	for _, p := range []Policy[int]{NewLRU[int](0), NewLFU[int](0), NewARC[int](-1)} {
+		if evicted := insertAll(p, 1, 2); !equalInts(evicted, []int{1}) {
+			t.Errorf("%T: expected a capacity of 1, got evictions %v", p, evicted)
+		}
+	}
+}
@@ -0,0 +1,314 @@
+// Package circuitbreaker provides a circuit breaker that stops calls to a
+// failing dependency and lets a limited number of probes through once it may
+// have recovered. It plugs into retry.Do by wrapping a retry.RetryFunc.
+package circuitbreaker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/kashifkhan0771/utils/retry"
+)
+
+// Default values used when the corresponding Options field is not set.
+const (
+	DefaultFailureThreshold = 5                // Consecutive failures that trip the breaker
+	DefaultWindow           = 10 * time.Second // Length of the rolling window for FailureRatio
+	DefaultOpenTimeout      = 30 * time.Second // Time spent open before probing
+	DefaultHalfOpenProbes   = 1                // Probes allowed while half-open
+)
+
+// ErrCircuitOpen is returned instead of calling the function while the
+// breaker is open, or half-open with all probes in flight.
+var ErrCircuitOpen = errors.New("circuitbreaker: circuit open")
+
+// State is the state of a CircuitBreaker.
+type State int
+
+// Circuit breaker states.
+const (
+	StateClosed   State = iota // StateClosed lets every call through and counts failures.
+	StateOpen                  // StateOpen rejects every call with ErrCircuitOpen.
+	StateHalfOpen              // StateHalfOpen lets a limited number of probes through.
+)
+
+// String returns the name of the state: closed, open or half-open.
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half-open"
+	default:
+		return fmt.Sprintf("State(%d)", int(s))
+	}
+}
+
+// Options configures a CircuitBreaker. If neither FailureThreshold nor
+// FailureRatio is set, the breaker trips after DefaultFailureThreshold
+// consecutive failures.
+type Options struct {
+	// FailureThreshold trips the breaker after this many consecutive failures.
+	// 0 disables the check.
+	FailureThreshold uint
+
+	// FailureRatio trips the breaker when the ratio of failed calls in the
+	// rolling Window reaches this value (0 < ratio <= 1), once at least
+	// MinRequests calls were made. 0 disables the check.
+	FailureRatio float64
+	MinRequests  uint
+	// Window is the length of the rolling window for FailureRatio.
+	// Defaults to DefaultWindow.
+	Window time.Duration
+
+	// OpenTimeout is how long the breaker stays open before letting probes
+	// through. Defaults to DefaultOpenTimeout.
+	OpenTimeout time.Duration
+
+	// HalfOpenProbes is the number of calls allowed through while half-open.
+	// The breaker closes once that many probes succeed and opens again on the
+	// first failed probe. Defaults to DefaultHalfOpenProbes.
+	HalfOpenProbes uint
+
+	// IsFailure reports whether an error counts as a failure. By default
+	// every error except context.Canceled does.
+	IsFailure func(err error) bool
+
+	// OnStateChange is called after every state change. It is called without
+	// holding the breaker's lock, so it may call State.
+	OnStateChange func(from, to State)
+}
+
+// CircuitBreaker tracks the outcome of calls to a dependency and rejects
+// calls while the dependency is considered down. It is safe for concurrent use.
+type CircuitBreaker struct {
+	mu          sync.Mutex
+	opts        Options
+	state       State
+	generation  uint64    // Incremented on every state change; results of older calls are ignored
+	consecutive uint      // Consecutive failures while closed
+	window      *window   // Call outcomes of the last Window while closed
+	openedAt    time.Time // When the breaker last opened
+	probes      uint      // Probes let through in the current half-open period
+	successes   uint      // Successful probes in the current half-open period
+	now         func() time.Time
+}
+
+// New creates a closed CircuitBreaker with the given options.
+func New(opts Options) *CircuitBreaker {
+	if opts.FailureThreshold == 0 && opts.FailureRatio <= 0 {
+		opts.FailureThreshold = DefaultFailureThreshold
+	}
+	if opts.Window <= 0 {
+		opts.Window = DefaultWindow
+	}
+	if opts.OpenTimeout <= 0 {
+		opts.OpenTimeout = DefaultOpenTimeout
+	}
+	if opts.HalfOpenProbes == 0 {
+		opts.HalfOpenProbes = DefaultHalfOpenProbes
+	}
+	if opts.IsFailure == nil {
+		opts.IsFailure = func(err error) bool { return !errors.Is(err, context.Canceled) }
+	}
+
+	return &CircuitBreaker{
+		opts:   opts,
+		window: newWindow(opts.Window),
+		now:    time.Now,
+	}
+}
+
+// State returns the current state of the breaker.
+func (cb *CircuitBreaker) State() State {
+	cb.mu.Lock()
+	notify := cb.refresh(cb.now())
+	state := cb.state
+	cb.mu.Unlock()
+
+	notify()
+
+	return state
+}
+
+// Allow reports whether a call may proceed. If it may, the returned done
+// function must be called exactly once with the call's error (nil on
+// success); otherwise Allow returns ErrCircuitOpen. Use Allow when the call
+// cannot be expressed as a function passed to Execute.
+func (cb *CircuitBreaker) Allow() (done func(err error), err error) {
+	cb.mu.Lock()
+	notify := cb.refresh(cb.now())
+
+	switch cb.state {
+	case StateOpen:
+		cb.mu.Unlock()
+		notify()
+
+		return nil, ErrCircuitOpen
+	case StateHalfOpen:
+		if cb.probes >= cb.opts.HalfOpenProbes {
+			cb.mu.Unlock()
+			notify()
+
+			return nil, ErrCircuitOpen
+		}
+		cb.probes++
+	}
+
+	generation := cb.generation
+	cb.mu.Unlock()
+	notify()
+
+	var once sync.Once
+
+	return func(err error) {
+		once.Do(func() { cb.record(generation, err) })
+	}, nil
+}
+
+// Execute calls fn if the breaker allows it and records the outcome. It
+// returns ErrCircuitOpen without calling fn while the breaker is open.
+func (cb *CircuitBreaker) Execute(ctx context.Context, fn func(ctx context.Context) error) error {
+	done, err := cb.Allow()
+	if err != nil {
+		return err
+	}
+
+	err = fn(ctx)
+	done(err)
+
+	return err
+}
+
+// Reset closes the breaker and clears all counts.
+func (cb *CircuitBreaker) Reset() {
+	cb.mu.Lock()
+	notify := cb.setState(StateClosed, cb.now())
+	cb.mu.Unlock()
+
+	notify()
+}
+
+// Wrap returns a retry.RetryFunc that calls fn through the breaker. While the
+// breaker is open, the returned function fails with ErrCircuitOpen without
+// calling fn; pair it with a ShouldRetry that returns false for
+// ErrCircuitOpen to stop retrying as soon as the breaker trips.
+func Wrap[T any](cb *CircuitBreaker, fn retry.RetryFunc[T]) retry.RetryFunc[T] {
+	return func(ctx context.Context) (T, error) {
+		var zero T
+		done, err := cb.Allow()
+		if err != nil {
+			return zero, err
+		}
+
+		ret, err := fn(ctx)
+		done(err)
+
+		return ret, err
+	}
+}
+
+// record updates the counts with the outcome of a call started in the given
+// generation.
+func (cb *CircuitBreaker) record(generation uint64, err error) {
+	failed := err != nil && cb.opts.IsFailure(err)
+
+	cb.mu.Lock()
+	now := cb.now()
+	notify := cb.refresh(now)
+	if generation != cb.generation {
+		cb.mu.Unlock()
+		notify()
+
+		return
+	}
+
+	switch cb.state {
+	case StateClosed:
+		cb.window.add(now, failed)
+		if !failed {
+			cb.consecutive = 0
+
+			break
+		}
+		cb.consecutive++
+		if cb.tripped() {
+			notify = chain(notify, cb.setState(StateOpen, now))
+		}
+	case StateHalfOpen:
+		if failed {
+			notify = chain(notify, cb.setState(StateOpen, now))
+
+			break
+		}
+		cb.successes++
+		if cb.successes >= cb.opts.HalfOpenProbes {
+			notify = chain(notify, cb.setState(StateClosed, now))
+		}
+	}
+	cb.mu.Unlock()
+
+	notify()
+}
+
+// tripped reports whether the failure counts call for opening the breaker.
+// Caller must hold the mutex.
+func (cb *CircuitBreaker) tripped() bool {
+	if cb.opts.FailureThreshold > 0 && cb.consecutive >= cb.opts.FailureThreshold {
+		return true
+	}
+	if cb.opts.FailureRatio <= 0 {
+		return false
+	}
+
+	total, failures := cb.window.counts()
+
+	return total > 0 && total >= uint64(cb.opts.MinRequests) &&
+		float64(failures)/float64(total) >= cb.opts.FailureRatio
+}
+
+// refresh moves an open breaker to half-open once OpenTimeout has elapsed.
+// Caller must hold the mutex; the returned function must be called after
+// releasing it.
+func (cb *CircuitBreaker) refresh(now time.Time) func() {
+	if cb.state == StateOpen && now.Sub(cb.openedAt) >= cb.opts.OpenTimeout {
+		return cb.setState(StateHalfOpen, now)
+	}
+
+	return func() {}
+}
+
+// setState changes the state and resets the counts. Caller must hold the
+// mutex; the returned function runs the OnStateChange callback and must be
+// called after releasing it.
+func (cb *CircuitBreaker) setState(state State, now time.Time) func() {
+	from := cb.state
+	cb.state = state
+	cb.generation++
+	cb.consecutive = 0
+	cb.probes = 0
+	cb.successes = 0
+	cb.window.reset()
+	if state == StateOpen {
+		cb.openedAt = now
+	}
+
+	if from == state || cb.opts.OnStateChange == nil {
+		return func() {}
+	}
+
+	return func() { cb.opts.OnStateChange(from, state) }
+}
+
+// chain returns a function that calls a and then b.
+func chain(a, b func()) func() {
+	return func() {
+		a()
+		b()
+	}
+}
@@ -0,0 +1,270 @@
+package circuitbreaker
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kashifkhan0771/utils/retry"
+)
+
+var errFail = errors.New("fail")
+
+// fakeClock is a manually advanced time source.
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func newTestBreaker(opts Options) (*CircuitBreaker, *fakeClock) {
+	clock := newFakeClock()
+	cb := New(opts)
+	cb.now = clock.Now
+
+	return cb, clock
+}
+
+func call(cb *CircuitBreaker, err error) error {
+	return cb.Execute(context.Background(), func(context.Context) error { return err })
+}
+
+func TestState_String(t *testing.T) {
+	tests := map[State]string{
+		StateClosed:   "closed",
+		StateOpen:     "open",
+		StateHalfOpen: "half-open",
+		State(7):      "State(7)",
+	}
+	for state, want := range tests {
+		if got := state.String(); got != want {
+			t.Errorf("String() = %q, want %q", got, want)
+		}
+	}
+}
+
+func TestCircuitBreaker_ConsecutiveFailures(t *testing.T) {
+	cb, _ := newTestBreaker(Options{FailureThreshold: 3})
+
+	_ = call(cb, errFail)
+	_ = call(cb, errFail)
+	_ = call(cb, nil) // resets the streak
+	_ = call(cb, errFail)
+	_ = call(cb, errFail)
+	if got := cb.State(); got != StateClosed {
+		t.Fatalf("expected closed, got %v", got)
+	}
+
+	_ = call(cb, errFail)
+	if got := cb.State(); got != StateOpen {
+		t.Fatalf("expected open, got %v", got)
+	}
+
+	called := false
+	err := cb.Execute(context.Background(), func(context.Context) error {
+		called = true
+
+		return nil
+	})
+	if !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected ErrCircuitOpen, got %v", err)
+	}
+	if called {
+		t.Fatal("expected fn not to be called while open")
+	}
+}
+
+func TestCircuitBreaker_FailureRatio(t *testing.T) {
+	cb, clock := newTestBreaker(Options{FailureRatio: 0.5, MinRequests: 4, Window: 10 * time.Second})
+
+	_ = call(cb, errFail)
+	_ = call(cb, errFail)
+	_ = call(cb, errFail)
+	if got := cb.State(); got != StateClosed {
+		t.Fatalf("expected closed below MinRequests, got %v", got)
+	}
+
+	// The failures above roll out of the window
+	clock.Advance(11 * time.Second)
+	_ = call(cb, nil)
+	_ = call(cb, nil)
+	_ = call(cb, errFail)
+	if got := cb.State(); got != StateClosed {
+		t.Fatalf("expected closed with expired failures, got %v", got)
+	}
+
+	_ = call(cb, errFail) // 2 of 4 calls failed
+	if got := cb.State(); got != StateOpen {
+		t.Fatalf("expected open at 50%% failures, got %v", got)
+	}
+}
+
+func TestCircuitBreaker_HalfOpen(t *testing.T) {
+	var transitions []string
+	cb, clock := newTestBreaker(Options{
+		FailureThreshold: 1,
+		OpenTimeout:      time.Minute,
+		HalfOpenProbes:   2,
+		OnStateChange: func(from, to State) {
+			transitions = append(transitions, from.String()+"->"+to.String())
+		},
+	})
+
+	_ = call(cb, errFail)
+	clock.Advance(59 * time.Second)
+	if got := cb.State(); got != StateOpen {
+		t.Fatalf("expected open before OpenTimeout, got %v", got)
+	}
+
+	clock.Advance(time.Second)
+	if got := cb.State(); got != StateHalfOpen {
+		t.Fatalf("expected half-open after OpenTimeout, got %v", got)
+	}
+
+	done1, err := cb.Allow()
+	if err != nil {
+		t.Fatalf("expected first probe to be allowed, got %v", err)
+	}
+	done2, err := cb.Allow()
+	if err != nil {
+		t.Fatalf("expected second probe to be allowed, got %v", err)
+	}
+	if _, err := cb.Allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected third probe to be rejected, got %v", err)
+	}
+
+	done1(nil)
+	if got := cb.State(); got != StateHalfOpen {
+		t.Fatalf("expected half-open after one successful probe, got %v", got)
+	}
+	done2(nil)
+	if got := cb.State(); got != StateClosed {
+		t.Fatalf("expected closed after all probes succeeded, got %v", got)
+	}
+
+	_ = call(cb, errFail)
+	clock.Advance(time.Minute)
+	_ = call(cb, errFail) // failed probe
+	if got := cb.State(); got != StateOpen {
+		t.Fatalf("expected open after a failed probe, got %v", got)
+	}
+
+	want := []string{
+		"closed->open", "open->half-open", "half-open->closed",
+		"closed->open", "open->half-open", "half-open->open",
+	}
+	if len(transitions) != len(want) {
+		t.Fatalf("transitions = %v, want %v", transitions, want)
+	}
+	for i := range want {
+		if transitions[i] != want[i] {
+			t.Fatalf("transitions = %v, want %v", transitions, want)
+		}
+	}
+}
+
+func TestCircuitBreaker_StaleResultsIgnored(t *testing.T) {
+	cb, _ := newTestBreaker(Options{FailureThreshold: 1})
+
+	slow, err := cb.Allow()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_ = call(cb, errFail)
+	cb.Reset()
+
+	slow(errFail) // started before the breaker opened and was reset
+	if got := cb.State(); got != StateClosed {
+		t.Fatalf("expected stale failure to be ignored, got %v", got)
+	}
+}
+
+func TestCircuitBreaker_IsFailure(t *testing.T) {
+	notFound := errors.New("not found")
+	cb, _ := newTestBreaker(Options{
+		FailureThreshold: 1,
+		IsFailure:        func(err error) bool { return !errors.Is(err, notFound) },
+	})
+
+	_ = call(cb, notFound)
+	_ = call(cb, context.Canceled)
+	if got := cb.State(); got != StateOpen {
+		t.Fatalf("expected a custom IsFailure to count context.Canceled, got %v", got)
+	}
+
+	cb, _ = newTestBreaker(Options{FailureThreshold: 1})
+	_ = call(cb, context.Canceled)
+	if got := cb.State(); got != StateClosed {
+		t.Fatalf("expected context.Canceled to be ignored by default, got %v", got)
+	}
+}
+
+func TestWrap_StopsRetryDo(t *testing.T) {
+	cb, _ := newTestBreaker(Options{FailureThreshold: 2})
+
+	attempts := 0
+	fn := Wrap(cb, func(ctx context.Context) (string, error) {
+		attempts++
+
+		return "", errFail
+	})
+
+	opts := retry.Options{
+		MaxAttempts: 5,
+		ShouldRetry: func(err error) bool { return !errors.Is(err, ErrCircuitOpen) },
+	}
+	_, err := retry.Do(context.Background(), opts, fn)
+	if !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected ErrCircuitOpen, got %v", err)
+	}
+	if attempts != 2 {
+		t.Fatalf("expected 2 calls before the breaker opened, got %d", attempts)
+	}
+
+	cb.Reset()
+	result, err := retry.Do(context.Background(), opts, Wrap(cb, func(ctx context.Context) (string, error) {
+		return "ok", nil
+	}))
+	if err != nil || result != "ok" {
+		t.Fatalf("expected ok after Reset, got %q, %v", result, err)
+	}
+}
+
+func TestCircuitBreaker_Concurrent(t *testing.T) {
+	cb := New(Options{FailureThreshold: 10, OpenTimeout: time.Millisecond})
+
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range 200 {
+				var err error
+				if (i+j)%3 == 0 {
+					err = errFail
+				}
+				_ = call(cb, err)
+				_ = cb.State()
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,66 @@
+package circuitbreaker
+
+import "time"
+
+// windowBuckets is the number of buckets the rolling window is divided into.
+const windowBuckets = 10
+
+// bucket counts the calls made during one slice of the rolling window.
+type bucket struct {
+	start    time.Time
+	total    uint64
+	failures uint64
+}
+
+// window counts call outcomes over a rolling period, in fixed-size buckets
+// that expire one at a time.
+type window struct {
+	size    time.Duration // Length of one bucket
+	buckets [windowBuckets]bucket
+}
+
+// newWindow creates a window covering the given period.
+func newWindow(period time.Duration) *window {
+	return &window{size: max(period/windowBuckets, 1)}
+}
+
+// add records the outcome of a call made at now.
+func (w *window) add(now time.Time, failed bool) {
+	start := now.Truncate(w.size)
+	b := &w.buckets[(start.UnixNano()/int64(w.size))%windowBuckets]
+	if !b.start.Equal(start) {
+		*b = bucket{start: start}
+	}
+
+	b.total++
+	if failed {
+		b.failures++
+	}
+	w.expire(start)
+}
+
+// expire clears buckets that are older than the window ending at the bucket
+// starting at latest.
+func (w *window) expire(latest time.Time) {
+	oldest := latest.Add(-time.Duration(windowBuckets-1) * w.size)
+	for i := range w.buckets {
+		if w.buckets[i].start.Before(oldest) || w.buckets[i].start.After(latest) {
+			w.buckets[i] = bucket{}
+		}
+	}
+}
+
+// counts returns the number of calls and failures in the window.
+func (w *window) counts() (total, failures uint64) {
+	for _, b := range w.buckets {
+		total += b.total
+		failures += b.failures
+	}
+
+	return total, failures
+}
+
+// reset clears all buckets.
+func (w *window) reset() {
+	w.buckets = [windowBuckets]bucket{}
+}
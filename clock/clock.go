@@ -0,0 +1,246 @@
+// Package clock provides an injectable source of time, with a real clock
+// backed by the time package and a fake clock that tests move forward by hand.
+package clock
+
+import (
+	"slices"
+	"sync"
+	"time"
+)
+
+// Clock tells the time and waits for it. Code that takes a Clock instead of
+// calling time.Now and time.After directly can be tested with a Fake clock.
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+	NewTimer(d time.Duration) Timer
+	Sleep(d time.Duration)
+}
+
+// Timer is a single event, like *time.Timer. Its channel is read with C().
+type Timer interface {
+	C() <-chan time.Time
+	Stop() bool
+	Reset(d time.Duration) bool
+}
+
+// New returns a Clock backed by the time package.
+func New() Clock {
+	return realClock{}
+}
+
+// OrDefault returns c, or the real clock if c is nil.
+func OrDefault(c Clock) Clock {
+	if c == nil {
+		return New()
+	}
+
+	return c
+}
+
+// realClock implements Clock with the time package.
+type realClock struct{}
+
+func (realClock) Now() time.Time                         { return time.Now() }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+func (realClock) NewTimer(d time.Duration) Timer         { return realTimer{time.NewTimer(d)} }
+func (realClock) Sleep(d time.Duration)                  { time.Sleep(d) }
+
+// realTimer implements Timer with a *time.Timer.
+type realTimer struct {
+	t *time.Timer
+}
+
+func (t realTimer) C() <-chan time.Time        { return t.t.C }
+func (t realTimer) Stop() bool                 { return t.t.Stop() }
+func (t realTimer) Reset(d time.Duration) bool { return t.t.Reset(d) }
+
+// Fake is a Clock that only moves when told to. Timers, After and Sleep fire
+// when Advance or Set moves the time past their deadline, in deadline order.
+// It is safe for concurrent use.
+type Fake struct {
+	mu      sync.Mutex
+	changed *sync.Cond // Broadcast when timers are added or removed
+	now     time.Time
+	timers  []*fakeTimer // Pending timers
+}
+
+// fakeTimer is a Timer of a Fake clock.
+type fakeTimer struct {
+	clock *Fake
+	when  time.Time
+	c     chan time.Time
+}
+
+// NewFake returns a Fake clock set to start.
+func NewFake(start time.Time) *Fake {
+	f := &Fake{now: start}
+	f.changed = sync.NewCond(&f.mu)
+
+	return f
+}
+
+// Now returns the current time of the clock.
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.now
+}
+
+// After returns a channel that receives the time once the clock has moved d forward.
+func (f *Fake) After(d time.Duration) <-chan time.Time {
+	return f.NewTimer(d).C()
+}
+
+// NewTimer returns a Timer that fires once the clock has moved d forward.
+// A timer with d <= 0 fires right away.
+func (f *Fake) NewTimer(d time.Duration) Timer {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	t := &fakeTimer{clock: f, c: make(chan time.Time, 1)}
+	f.schedule(t, d)
+
+	return t
+}
+
+// Sleep blocks until the clock has moved d forward. It returns right away if d <= 0.
+func (f *Fake) Sleep(d time.Duration) {
+	if d <= 0 {
+		return
+	}
+
+	<-f.After(d)
+}
+
+// Advance moves the clock forward by d, firing the timers due on the way in
+// deadline order. Each timer receives its own deadline.
+func (f *Fake) Advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.advanceTo(f.now.Add(d))
+}
+
+// Set moves the clock to t. Moving forward fires the timers due on the way,
+// as Advance does; moving backwards fires nothing.
+func (f *Fake) Set(t time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if t.Before(f.now) {
+		f.now = t
+
+		return
+	}
+	f.advanceTo(t)
+}
+
+// Timers returns the number of timers waiting to fire, including the ones
+// behind After and Sleep.
+func (f *Fake) Timers() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return len(f.timers)
+}
+
+// WaitForTimers blocks until at least n timers are waiting to fire. Use it to
+// make sure a goroutine is blocked in Sleep or on a timer before calling Advance.
+func (f *Fake) WaitForTimers(n int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for len(f.timers) < n {
+		f.changed.Wait()
+	}
+}
+
+// advanceTo moves the clock to t, firing due timers. Caller must hold the mutex.
+func (f *Fake) advanceTo(t time.Time) {
+	for len(f.timers) > 0 {
+		// Fire the earliest timer first
+		timer := slices.MinFunc(f.timers, func(a, b *fakeTimer) int { return a.when.Compare(b.when) })
+		if timer.when.After(t) {
+			break
+		}
+
+		f.remove(timer)
+		if timer.when.After(f.now) {
+			f.now = timer.when
+		}
+		timer.fire(timer.when)
+	}
+	f.now = t
+}
+
+// schedule adds t to fire in d, or fires it right away if d <= 0.
+// Caller must hold the mutex.
+func (f *Fake) schedule(t *fakeTimer, d time.Duration) {
+	t.when = f.now.Add(d)
+	if d <= 0 {
+		t.fire(f.now)
+
+		return
+	}
+	f.timers = append(f.timers, t)
+	f.changed.Broadcast()
+}
+
+// remove deletes t from the pending timers and reports whether it was pending.
+// Caller must hold the mutex.
+func (f *Fake) remove(t *fakeTimer) bool {
+	i := slices.Index(f.timers, t)
+	if i < 0 {
+		return false
+	}
+	f.timers = slices.Delete(f.timers, i, i+1)
+	f.changed.Broadcast()
+
+	return true
+}
+
+func (t *fakeTimer) C() <-chan time.Time {
+	return t.c
+}
+
+// Stop prevents the timer from firing. It returns false if the timer already fired or was stopped.
+func (t *fakeTimer) Stop() bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+
+	t.drain()
+
+	return t.clock.remove(t)
+}
+
+// Reset changes the timer to fire once the clock has moved d forward.
+// It returns true if the timer was still pending.
+func (t *fakeTimer) Reset(d time.Duration) bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+
+	t.drain()
+	pending := t.clock.remove(t)
+	t.clock.schedule(t, d)
+
+	return pending
+}
+
+// fire sends now on the timer's channel without blocking.
+func (t *fakeTimer) fire(now time.Time) {
+	select {
+	case t.c <- now:
+	default:
+	}
+}
+
+// drain removes a value not received yet from the timer's channel, like
+// Stop and Reset of *time.Timer do since Go 1.23.
+func (t *fakeTimer) drain() {
+	select {
+	case <-t.c:
+	default:
+	}
+}
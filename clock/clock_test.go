@@ -0,0 +1,153 @@
+package clock
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+var start = time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+func TestFake_Now(t *testing.T) {
+	f := NewFake(start)
+	if !f.Now().Equal(start) {
+		t.Fatalf("expected %v, got %v", start, f.Now())
+	}
+
+	f.Advance(time.Hour)
+	if want := start.Add(time.Hour); !f.Now().Equal(want) {
+		t.Fatalf("expected %v, got %v", want, f.Now())
+	}
+
+	f.Set(start)
+	if !f.Now().Equal(start) {
+		t.Fatalf("expected Set to move the clock back to %v, got %v", start, f.Now())
+	}
+}
+
+func TestFake_TimersFireInOrder(t *testing.T) {
+	f := NewFake(start)
+	late := f.NewTimer(3 * time.Second)
+	early := f.NewTimer(time.Second)
+	never := f.NewTimer(time.Minute)
+
+	f.Advance(5 * time.Second)
+
+	if got := <-early.C(); !got.Equal(start.Add(time.Second)) {
+		t.Errorf("expected the early timer to receive its deadline, got %v", got)
+	}
+	if got := <-late.C(); !got.Equal(start.Add(3 * time.Second)) {
+		t.Errorf("expected the late timer to receive its deadline, got %v", got)
+	}
+	select {
+	case <-never.C():
+		t.Error("expected the timer due later not to fire")
+	default:
+	}
+	if f.Timers() != 1 {
+		t.Errorf("expected 1 pending timer, got %d", f.Timers())
+	}
+}
+
+func TestFake_StopAndReset(t *testing.T) {
+	f := NewFake(start)
+	timer := f.NewTimer(time.Second)
+
+	if !timer.Stop() {
+		t.Error("expected Stop to report a pending timer")
+	}
+	if timer.Stop() {
+		t.Error("expected a second Stop to report a stopped timer")
+	}
+	f.Advance(2 * time.Second)
+	select {
+	case <-timer.C():
+		t.Fatal("expected a stopped timer not to fire")
+	default:
+	}
+
+	if timer.Reset(time.Second) {
+		t.Error("expected Reset to report a stopped timer")
+	}
+	f.Advance(time.Second)
+	if got := <-timer.C(); !got.Equal(start.Add(3 * time.Second)) {
+		t.Errorf("expected the reset timer to fire at its new deadline, got %v", got)
+	}
+
+	// Reset drops a value that was not received
+	f.Advance(0)
+	timer.Reset(time.Second)
+	f.Advance(time.Second)
+	timer.Reset(time.Second)
+	select {
+	case <-timer.C():
+		t.Error("expected Reset to drain the fired value")
+	default:
+	}
+}
+
+func TestFake_ZeroDurationFiresImmediately(t *testing.T) {
+	f := NewFake(start)
+
+	select {
+	case got := <-f.After(0):
+		if !got.Equal(start) {
+			t.Errorf("expected %v, got %v", start, got)
+		}
+	default:
+		t.Fatal("expected After(0) to fire right away")
+	}
+	f.Sleep(-time.Second) // Must not block
+}
+
+func TestFake_Sleep(t *testing.T) {
+	f := NewFake(start)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		f.Sleep(time.Minute)
+	}()
+
+	f.WaitForTimers(1)
+	f.Advance(time.Minute)
+	wg.Wait()
+}
+
+func TestFake_Set(t *testing.T) {
+	f := NewFake(start)
+	timer := f.NewTimer(time.Hour)
+
+	f.Set(start.Add(2 * time.Hour))
+	if got := <-timer.C(); !got.Equal(start.Add(time.Hour)) {
+		t.Errorf("expected the timer to fire at its deadline, got %v", got)
+	}
+}
+
+func TestReal(t *testing.T) {
+	c := New()
+	before := time.Now()
+	if c.Now().Before(before) {
+		t.Error("expected the real clock to tell the current time")
+	}
+
+	timer := c.NewTimer(time.Millisecond)
+	<-timer.C()
+	if timer.Stop() {
+		t.Error("expected Stop to report a fired timer")
+	}
+	<-c.After(time.Millisecond)
+	c.Sleep(time.Millisecond)
+}
+
+func TestOrDefault(t *testing.T) {
+	if _, ok := OrDefault(nil).(realClock); !ok {
+		t.Error("expected the real clock for nil")
+	}
+
+	f := NewFake(start)
+	if OrDefault(f) != f {
+		t.Error("expected the given clock")
+	}
+}
@@ -0,0 +1,238 @@
+package logging
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// DefaultAsyncBufferSize is the queue capacity used when AsyncOptions.BufferSize is not set.
+const DefaultAsyncBufferSize = 1024
+
+// OverflowPolicy defines what an asynchronous logger does when its queue is full.
+type OverflowPolicy int
+
+// Supported overflow policies.
+const (
+	OverflowBlock      OverflowPolicy = iota // OverflowBlock waits until there is room in the queue.
+	OverflowDropNewest                       // OverflowDropNewest discards the entry being logged.
+	OverflowDropOldest                       // OverflowDropOldest discards the oldest queued entry.
+)
+
+// AsyncOptions configures asynchronous logging.
+type AsyncOptions struct {
+	BufferSize    int            // Capacity of the queue; defaults to DefaultAsyncBufferSize
+	Overflow      OverflowPolicy // Behavior when the queue is full
+	SyncLevel     LogLevel       // Entries at or above this level bypass the queue; the zero value (DEBUG) means ERROR
+	DisableBypass bool           // Queue entries of every level, ignoring SyncLevel
+}
+
+// queuedEntry is an entry waiting to be written by the logger that created it.
+type queuedEntry struct {
+	logger *Logger
+	entry  *Entry
+	format entryFormat // Format of the logger when the entry was logged
+}
+
+// asyncQueue is a bounded ring buffer of entries drained by a single
+// background goroutine.
+type asyncQueue struct {
+	mu        sync.Mutex
+	cond      *sync.Cond // Signaled on every change of the queue state
+	items     []queuedEntry
+	head      int
+	size      int
+	pushed    uint64         // Number of entries ever added to the queue
+	processed uint64         // Number of queued entries written or dropped
+	dropped   *atomic.Uint64 // Shared counter of discarded entries
+	closed    bool
+	opts      AsyncOptions
+	done      chan struct{}
+}
+
+// newAsyncQueue creates a queue that counts discarded entries in dropped and
+// starts its writer goroutine.
+func newAsyncQueue(opts AsyncOptions, dropped *atomic.Uint64) *asyncQueue {
+	if opts.BufferSize < 1 {
+		opts.BufferSize = DefaultAsyncBufferSize
+	}
+	if opts.SyncLevel == DEBUG {
+		opts.SyncLevel = ERROR
+	}
+
+	q := &asyncQueue{
+		items:   make([]queuedEntry, opts.BufferSize),
+		dropped: dropped,
+		opts:    opts,
+		done:    make(chan struct{}),
+	}
+	q.cond = sync.NewCond(&q.mu)
+
+	go q.run()
+
+	return q
+}
+
+// bypass reports whether entries of the given level are written synchronously.
+func (q *asyncQueue) bypass(level LogLevel) bool {
+	return !q.opts.DisableBypass && level >= q.opts.SyncLevel
+}
+
+// push adds an entry to the queue, applying the overflow policy if it is full.
+// It returns false if the queue is closed and the caller must write the entry itself.
+func (q *asyncQueue) push(item queuedEntry) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for !q.closed && q.size == len(q.items) {
+		switch q.opts.Overflow {
+		case OverflowDropNewest:
+			q.dropped.Add(1)
+
+			return true
+		case OverflowDropOldest:
+			q.items[q.head] = queuedEntry{}
+			q.head = (q.head + 1) % len(q.items)
+			q.size--
+			q.processed++
+			q.dropped.Add(1)
+		default:
+			q.cond.Wait()
+		}
+	}
+	if q.closed {
+		return false
+	}
+
+	q.items[(q.head+q.size)%len(q.items)] = item
+	q.size++
+	q.pushed++
+	q.cond.Broadcast()
+
+	return true
+}
+
+// run writes queued entries until the queue is closed and drained.
+func (q *asyncQueue) run() {
+	defer close(q.done)
+
+	q.mu.Lock()
+	for {
+		for q.size == 0 && !q.closed {
+			q.cond.Wait()
+		}
+		if q.size == 0 {
+			q.mu.Unlock()
+
+			return
+		}
+
+		item := q.items[q.head]
+		q.items[q.head] = queuedEntry{}
+		q.head = (q.head + 1) % len(q.items)
+		q.size--
+		q.cond.Broadcast()
+		q.mu.Unlock()
+
+		item.logger.writeEntry(item.entry, item.format)
+
+		q.mu.Lock()
+		q.processed++
+		q.cond.Broadcast()
+	}
+}
+
+// flush blocks until every entry queued before the call has been written or dropped.
+func (q *asyncQueue) flush() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	target := q.pushed
+	for q.processed < target {
+		q.cond.Wait()
+	}
+}
+
+// close stops accepting entries and waits for the queue to drain.
+func (q *asyncQueue) close() {
+	q.mu.Lock()
+	q.closed = true
+	q.cond.Broadcast()
+	q.mu.Unlock()
+
+	<-q.done
+}
+
+// EnableAsync switches the logger (and the loggers sharing its sinks) to
+// asynchronous mode: entries are placed in a bounded queue and written by a
+// background goroutine. Entries at or above AsyncOptions.SyncLevel bypass the
+// queue; they are written synchronously after the entries queued before them.
+// Calling EnableAsync again drains the current queue and applies the new options.
+//
+// Parameters:
+//   - opts: Queue size, overflow policy and synchronous bypass level.
+func (l *Logger) EnableAsync(opts AsyncOptions) {
+	l.out.mu.Lock()
+	old := l.out.async
+	l.out.async = newAsyncQueue(opts, &l.out.dropped)
+	l.out.mu.Unlock()
+
+	if old != nil {
+		old.close()
+	}
+}
+
+// Flush blocks until all entries queued before the call have been written.
+// It is a no-op for synchronous loggers.
+func (l *Logger) Flush() {
+	if q := l.out.queue(); q != nil {
+		q.flush()
+	}
+}
+
+// Close writes the summaries of pending duplicate bursts, then drains the
+// asynchronous queue and stops its goroutine; later entries are written
+// synchronously. Close does not close the sinks' writers.
+func (l *Logger) Close() error {
+	if d := l.out.dedup(); d != nil {
+		emitSummaries(d.stop(), l.out.now())
+	}
+
+	l.out.mu.Lock()
+	q := l.out.async
+	l.out.async = nil
+	l.out.mu.Unlock()
+
+	if q != nil {
+		q.close()
+	}
+
+	return nil
+}
+
+// Dropped returns the total number of entries discarded because the
+// asynchronous queue was full.
+func (l *Logger) Dropped() uint64 {
+	return l.out.dropped.Load()
+}
+
+// dispatch writes the entry in the given format directly or hands it to the
+// asynchronous queue.
+func (l *Logger) dispatch(entry *Entry, format entryFormat) {
+	q := l.out.queue()
+	if q == nil {
+		l.writeEntry(entry, format)
+
+		return
+	}
+
+	if q.bypass(entry.Level) {
+		q.flush()
+		l.writeEntry(entry, format)
+
+		return
+	}
+
+	if !q.push(queuedEntry{logger: l, entry: entry, format: format}) {
+		l.writeEntry(entry, format)
+	}
+}
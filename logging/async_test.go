@@ -0,0 +1,227 @@
+package logging_test
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kashifkhan0771/utils/logging"
+)
+
+// gatedWriter blocks every write until it is released, and signals when the
+// first write has started.
+type gatedWriter struct {
+	mu      sync.Mutex
+	buf     bytes.Buffer
+	started chan struct{}
+	release chan struct{}
+	once    sync.Once
+}
+
+func newGatedWriter() *gatedWriter {
+	return &gatedWriter{started: make(chan struct{}), release: make(chan struct{})}
+}
+
+func (w *gatedWriter) Write(p []byte) (int, error) {
+	w.once.Do(func() { close(w.started) })
+	<-w.release
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.buf.Write(p)
+}
+
+func (w *gatedWriter) String() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.buf.String()
+}
+
+// messages extracts the message of each text line written by a "T" logger.
+func messages(output string) []string {
+	var msgs []string
+	for line := range strings.SplitSeq(strings.TrimSpace(output), "\n") {
+		if _, msg, ok := strings.Cut(line, "T: "); ok {
+			msgs = append(msgs, msg)
+		}
+	}
+
+	return msgs
+}
+
+func TestAsyncLoggerFlush(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	logger := logging.NewLogger("T", logging.DEBUG, buffer)
+	logger.SetColors(false)
+	logger.EnableAsync(logging.AsyncOptions{BufferSize: 4})
+	defer logger.Close()
+
+	child := logger.With("child", true)
+	for _, msg := range []string{"a", "b", "c", "d", "e", "f"} {
+		child.Info(msg)
+	}
+	logger.Flush()
+
+	want := "a child=true,b child=true,c child=true,d child=true,e child=true,f child=true"
+	if got := strings.Join(messages(buffer.String()), ","); got != want {
+		t.Errorf("messages = %q, want %q", got, want)
+	}
+}
+
+func TestAsyncLoggerOverflow(t *testing.T) {
+	tests := []struct {
+		name        string
+		policy      logging.OverflowPolicy
+		want        string
+		wantDropped uint64
+	}{
+		{
+			name:        "success - drop newest keeps queued entries",
+			policy:      logging.OverflowDropNewest,
+			want:        "1,2,3",
+			wantDropped: 2,
+		},
+		{
+			name:        "success - drop oldest keeps latest entries",
+			policy:      logging.OverflowDropOldest,
+			want:        "1,4,5",
+			wantDropped: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			writer := newGatedWriter()
+			logger := logging.NewLogger("T", logging.DEBUG, writer)
+			logger.SetColors(false)
+			logger.EnableAsync(logging.AsyncOptions{BufferSize: 2, Overflow: tt.policy})
+
+			logger.Info("1")
+			<-writer.started // the writer goroutine holds "1", the queue is empty
+			for _, msg := range []string{"2", "3", "4", "5"} {
+				logger.Info(msg)
+			}
+
+			if got := logger.Dropped(); got != tt.wantDropped {
+				t.Errorf("Dropped() = %d, want %d", got, tt.wantDropped)
+			}
+
+			close(writer.release)
+			if err := logger.Close(); err != nil {
+				t.Fatalf("Close() error = %v", err)
+			}
+			if got := strings.Join(messages(writer.String()), ","); got != tt.want {
+				t.Errorf("messages = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAsyncLoggerBlockPolicy(t *testing.T) {
+	writer := newGatedWriter()
+	logger := logging.NewLogger("T", logging.DEBUG, writer)
+	logger.SetColors(false)
+	logger.EnableAsync(logging.AsyncOptions{BufferSize: 1, Overflow: logging.OverflowBlock})
+
+	logger.Info("1")
+	<-writer.started
+	logger.Info("2") // fills the queue
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		logger.Info("3") // blocks until there is room
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("expected Info to block while the queue is full")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(writer.release)
+	<-done
+	logger.Flush()
+
+	if got := strings.Join(messages(writer.String()), ","); got != "1,2,3" {
+		t.Errorf("messages = %q, want %q", got, "1,2,3")
+	}
+	if got := logger.Dropped(); got != 0 {
+		t.Errorf("Dropped() = %d, want 0", got)
+	}
+	_ = logger.Close()
+}
+
+func TestAsyncLoggerSyncBypass(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	logger := logging.NewLogger("T", logging.DEBUG, buffer)
+	logger.SetColors(false)
+	logger.EnableAsync(logging.AsyncOptions{})
+	defer logger.Close()
+
+	logger.Info("queued")
+	logger.Warn("queued too")
+	logger.Error("written now")
+
+	// No Flush: the ERROR entry, and everything before it, is already written
+	if got := strings.Join(messages(buffer.String()), ","); got != "queued,queued too,written now" {
+		t.Errorf("messages = %q, want %q", got, "queued,queued too,written now")
+	}
+}
+
+func TestAsyncLoggerFormatChange(t *testing.T) {
+	writer := newGatedWriter()
+	logger := logging.NewLogger("T", logging.DEBUG, writer)
+	logger.SetColors(false)
+	logger.EnableAsync(logging.AsyncOptions{DisableBypass: true})
+	defer logger.Close()
+
+	logger.Info("a")
+	<-writer.started
+	logger.Info("b")
+
+	// Entries already logged keep the format they were logged with
+	logger.SetEncoder(logging.NewJSONEncoder(logging.EncoderConfig{}))
+	logger.SetColors(true)
+	close(writer.release)
+	logger.Flush()
+
+	if got := strings.Join(messages(writer.String()), ","); got != "a,b" {
+		t.Errorf("messages = %q, want %q", got, "a,b")
+	}
+	if strings.Contains(writer.String(), "\033[") {
+		t.Errorf("output contains color codes: %q", writer.String())
+	}
+}
+
+func TestAsyncLoggerClose(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	logger := logging.NewLogger("T", logging.DEBUG, buffer)
+	logger.SetColors(false)
+	logger.EnableAsync(logging.AsyncOptions{DisableBypass: true})
+
+	logger.Error("queued")
+	if err := logger.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	logger.Info("after close")
+
+	if got := strings.Join(messages(buffer.String()), ","); got != "queued,after close" {
+		t.Errorf("messages = %q, want %q", got, "queued,after close")
+	}
+}
+
+func BenchmarkAsyncLogger(b *testing.B) {
+	logger := logging.NewLogger("Test", logging.INFO, io.Discard)
+	logger.EnableAsync(logging.AsyncOptions{Overflow: logging.OverflowDropNewest})
+	defer logger.Close()
+	b.ReportAllocs()
+	for b.Loop() {
+		logger.Info("This is an info message")
+	}
+}
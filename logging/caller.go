@@ -0,0 +1,143 @@
+package logging
+
+import (
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// maxStackDepth is the maximum number of frames captured for a stack trace.
+const maxStackDepth = 64
+
+// callerFrames is the number of frames between runtime.Callers in capture and
+// the code that called a logging method such as Info: capture, logAt, log and
+// the logging method itself.
+const callerFrames = 5
+
+// Caller is the source location of a logging call.
+type Caller struct {
+	Function string  // Fully qualified function name, e.g. "main.run"
+	File     string  // Absolute path of the source file
+	Line     int     // Line number in File
+	pc       uintptr // Program counter, passed on to slog records
+}
+
+// String returns the location in short "dir/file.go:line" form.
+func (c Caller) String() string {
+	dir, file := filepath.Split(c.File)
+	if dir != "" {
+		file = filepath.Base(dir) + "/" + file
+	}
+
+	return file + ":" + strconv.Itoa(c.Line)
+}
+
+// EnableCaller annotates every entry with the file, line and function of the
+// logging call. Libraries that wrap the Logger can pass the number of their
+// own frames in skip so that the location of their caller is reported. The
+// setting is inherited by child loggers created afterwards. Records logged
+// through NewSlogHandler use the location recorded by log/slog and ignore skip.
+//
+// Parameters:
+//   - skip: Number of additional stack frames to skip; 0 reports the direct caller.
+func (l *Logger) EnableCaller(skip int) {
+	l.addCaller = true
+	l.callerSkip = max(skip, 0)
+}
+
+// DisableCaller stops annotating entries with their caller.
+func (l *Logger) DisableCaller() {
+	l.addCaller = false
+	l.callerSkip = 0
+}
+
+// EnableStackTrace attaches a stack trace of the logging goroutine to every
+// entry at or above minLevel. The trace starts at the logging call (taking
+// the skip set with EnableCaller into account) and goes through redaction
+// like the message does. The setting is inherited by child loggers created
+// afterwards.
+//
+// Parameters:
+//   - minLevel: The minimum level of entries that get a stack trace.
+func (l *Logger) EnableStackTrace(minLevel LogLevel) {
+	l.addStack = true
+	l.stackLevel = minLevel
+}
+
+// DisableStackTrace stops attaching stack traces to entries.
+func (l *Logger) DisableStackTrace() {
+	l.addStack = false
+}
+
+// wantsStack reports whether entries of the given level get a stack trace.
+func (l *Logger) wantsStack(level LogLevel) bool {
+	return l.addStack && level >= l.stackLevel
+}
+
+// capture returns the program counters of the logging call and its callers.
+// If pc is zero, the call is located by counting frames from the logging
+// method; otherwise frames above pc are discarded. Only the first frame is
+// captured when no stack trace is needed. It must be called directly by logAt.
+func (l *Logger) capture(pc uintptr, stack bool) []uintptr {
+	if pc != 0 {
+		if !stack {
+			return []uintptr{pc}
+		}
+
+		pcs := make([]uintptr, maxStackDepth)
+		pcs = pcs[:runtime.Callers(3, pcs)]
+		for i, p := range pcs {
+			if p == pc {
+				return pcs[i:]
+			}
+		}
+
+		return []uintptr{pc}
+	}
+
+	depth := 1
+	if stack {
+		depth = maxStackDepth
+	}
+	pcs := make([]uintptr, depth)
+
+	return pcs[:runtime.Callers(callerFrames+l.callerSkip, pcs)]
+}
+
+// callerOf returns the location of the first frame in pcs, or nil if pcs is
+// empty.
+func callerOf(pcs []uintptr) *Caller {
+	if len(pcs) == 0 {
+		return nil
+	}
+
+	frame, _ := runtime.CallersFrames(pcs[:1]).Next()
+
+	return &Caller{Function: frame.Function, File: frame.File, Line: frame.Line, pc: pcs[0]}
+}
+
+// formatStack renders pcs like a goroutine trace in a panic: one line with
+// the function name, followed by a tab-indented "file:line" line per frame.
+func formatStack(pcs []uintptr) string {
+	var b strings.Builder
+	frames := runtime.CallersFrames(pcs)
+	for {
+		frame, more := frames.Next()
+		if frame.Function != "" {
+			if b.Len() > 0 {
+				b.WriteString("\n")
+			}
+			b.WriteString(frame.Function)
+			b.WriteString("\n\t")
+			b.WriteString(frame.File)
+			b.WriteString(":")
+			b.WriteString(strconv.Itoa(frame.Line))
+		}
+		if !more {
+			break
+		}
+	}
+
+	return b.String()
+}
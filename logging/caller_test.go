@@ -0,0 +1,167 @@
+package logging_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/kashifkhan0771/utils/logging"
+)
+
+// line returns the line number of its caller.
+func line() int {
+	_, _, line, _ := runtime.Caller(1)
+
+	return line
+}
+
+// logWrapped logs through an extra frame, like a library wrapping the Logger.
+func logWrapped(logger *logging.Logger, message string) {
+	logger.Info(message)
+}
+
+func TestLoggerCaller(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	logger := logging.NewLogger("T", logging.DEBUG, buffer)
+	logger.SetColors(false)
+	logger.EnableCaller(0)
+
+	logger.Infow("direct", "k", "v")
+	want := "logging/caller_test.go:" + strconv.Itoa(line()-1)
+	if got := buffer.String(); !strings.Contains(got, "T: direct caller="+want+" function=github.com/kashifkhan0771/utils/logging_test.TestLoggerCaller k=v") {
+		t.Errorf("Expected caller %q, got %q", want, got)
+	}
+
+	buffer.Reset()
+	logger.With("child", true).Error("from child")
+	want = "caller_test.go:" + strconv.Itoa(line()-1)
+	if got := buffer.String(); !strings.Contains(got, want) {
+		t.Errorf("Expected child logger to inherit caller annotation %q, got %q", want, got)
+	}
+
+	buffer.Reset()
+	logger.EnableCaller(1)
+	logWrapped(logger, "wrapped")
+	want = "caller_test.go:" + strconv.Itoa(line()-1)
+	if got := buffer.String(); !strings.Contains(got, want) || !strings.Contains(got, "function=github.com/kashifkhan0771/utils/logging_test.TestLoggerCaller") {
+		t.Errorf("Expected skip to report the wrapper's caller %q, got %q", want, got)
+	}
+
+	buffer.Reset()
+	logger.DisableCaller()
+	logger.Info("plain")
+	if got := buffer.String(); strings.Contains(got, "caller=") {
+		t.Errorf("Expected no caller after DisableCaller, got %q", got)
+	}
+}
+
+func TestLoggerStackTrace(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	logger := logging.NewLogger("T", logging.DEBUG, buffer)
+	logger.SetColors(false)
+	logger.EnableStackTrace(logging.ERROR)
+
+	logger.Warn("no stack")
+	if got := buffer.String(); strings.Count(got, "\n") != 1 {
+		t.Errorf("Expected a single line below the stack trace level, got %q", got)
+	}
+
+	buffer.Reset()
+	logger.Error("with stack")
+	lines := strings.Split(strings.TrimSpace(buffer.String()), "\n")
+	if len(lines) < 3 {
+		t.Fatalf("Expected a stack trace, got %q", buffer.String())
+	}
+	if lines[1] != "github.com/kashifkhan0771/utils/logging_test.TestLoggerStackTrace" {
+		t.Errorf("Expected the trace to start at the logging call, got %q", lines[1])
+	}
+	if !strings.HasPrefix(lines[2], "\t") || !strings.Contains(lines[2], "caller_test.go:") {
+		t.Errorf("Expected a file:line line, got %q", lines[2])
+	}
+	if strings.Contains(buffer.String(), "utils/logging.(*Logger)") {
+		t.Errorf("Expected logging frames to be skipped, got %q", buffer.String())
+	}
+}
+
+func TestLoggerCallerEncoders(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	logger := logging.NewLogger("T", logging.DEBUG, buffer)
+	logger.SetEncoder(logging.NewJSONEncoder(logging.EncoderConfig{}))
+	logger.EnableCaller(0)
+	logger.EnableStackTrace(logging.ERROR)
+	if err := logger.SetRedactionRegex(map[string]string{`TestLoggerCallerEncoders`: "[REDACTED]"}); err != nil {
+		t.Fatalf("SetRedactionRegex() error = %v", err)
+	}
+
+	logger.Errorw("failed", "attempt", 3)
+
+	var got map[string]any
+	if err := json.Unmarshal(buffer.Bytes(), &got); err != nil {
+		t.Fatalf("Expected valid JSON, got %q: %v", buffer.String(), err)
+	}
+	if caller, _ := got["caller"].(string); !strings.HasPrefix(caller, "logging/caller_test.go:") {
+		t.Errorf("caller = %v", got["caller"])
+	}
+	if got["function"] != "github.com/kashifkhan0771/utils/logging_test.TestLoggerCallerEncoders" {
+		t.Errorf("function = %v", got["function"])
+	}
+	stack, _ := got["stack"].(string)
+	if !strings.HasPrefix(stack, "github.com/kashifkhan0771/utils/logging_test.[REDACTED]\n\t") {
+		t.Errorf("Expected a redacted stack trace, got %q", stack)
+	}
+
+	buffer.Reset()
+	logger.SetEncoder(logging.NewLogfmtEncoder(logging.EncoderConfig{}))
+	logger.Error("failed")
+	if got := buffer.String(); !strings.Contains(got, " caller=logging/caller_test.go:") || !strings.Contains(got, ` stack="github.com/`) {
+		t.Errorf("Unexpected logfmt output: %q", got)
+	}
+	if strings.Count(buffer.String(), "\n") != 1 {
+		t.Errorf("Expected logfmt output on a single line, got %q", buffer.String())
+	}
+}
+
+func TestSlogHandlerCaller(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	logger := logging.NewLogger("T", logging.DEBUG, buffer)
+	logger.SetColors(false)
+	logger.EnableCaller(3) // ignored for records coming from log/slog
+	logger.EnableStackTrace(logging.ERROR)
+
+	slog.New(logging.NewSlogHandler(logger)).Error("from slog")
+	want := "caller_test.go:" + strconv.Itoa(line()-1)
+
+	lines := strings.Split(strings.TrimSpace(buffer.String()), "\n")
+	if !strings.Contains(lines[0], want) {
+		t.Errorf("Expected caller %q, got %q", want, lines[0])
+	}
+	if len(lines) < 2 || lines[1] != "github.com/kashifkhan0771/utils/logging_test.TestSlogHandlerCaller" {
+		t.Errorf("Expected the trace to start at the slog call, got %q", buffer.String())
+	}
+}
+
+func TestSlogLoggerCaller(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	logger := logging.NewSlogLogger("", logging.DEBUG, slog.NewTextHandler(buffer, &slog.HandlerOptions{AddSource: true}))
+	logger.EnableCaller(0)
+
+	logger.Info("hello")
+	want := "caller_test.go:" + strconv.Itoa(line()-1)
+	if got := buffer.String(); !strings.Contains(got, want) {
+		t.Errorf("Expected the slog source %q, got %q", want, got)
+	}
+}
+
+func BenchmarkLoggerCaller(b *testing.B) {
+	logger := logging.NewLogger("Test", logging.INFO, io.Discard)
+	logger.EnableCaller(0)
+	b.ReportAllocs()
+	for b.Loop() {
+		logger.Info("This is an info message")
+	}
+}
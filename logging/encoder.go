@@ -0,0 +1,339 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Keys of the built-in entry attributes, used by EncoderConfig.Order and as
+// the JSON and logfmt key names.
+const (
+	KeyTime     = "time"     // Timestamp of the entry
+	KeyLevel    = "level"    // Level name of the entry
+	KeyPrefix   = "prefix"   // Logger prefix, omitted when empty
+	KeyMessage  = "msg"      // Log message
+	KeyCaller   = "caller"   // Location of the logging call, written with KeyFunction; omitted when not captured
+	KeyFunction = "function" // Function of the logging call, written at the position of KeyCaller
+	KeyFields   = "fields"   // Position of the structured fields
+	KeyStack    = "stack"    // Stack trace, omitted when not captured
+)
+
+// Default timestamp layouts of the built-in encoders.
+const (
+	DefaultTextTimeLayout = "2006-01-02 15:04:05" // Used by the text encoder
+	DefaultTimeLayout     = time.RFC3339Nano      // Used by the JSON and logfmt encoders
+)
+
+// defaultOrder is the order in which the built-in keys are written by default.
+var defaultOrder = []string{KeyTime, KeyLevel, KeyPrefix, KeyMessage, KeyCaller, KeyFields, KeyStack}
+
+// Entry is a single log record, passed to an Encoder after level filtering and
+// redaction have been applied.
+type Entry struct {
+	Time    time.Time // Time the entry was created
+	Level   LogLevel  // Severity of the entry
+	Prefix  string    // Prefix of the logger that created the entry
+	Message string    // Log message
+	Fields  []Field   // Structured fields, in the order they were added
+	Caller  *Caller   // Location of the logging call; nil unless enabled with EnableCaller
+	Stack   string    // Stack trace of the logging goroutine; empty unless enabled with EnableStackTrace
+}
+
+// Encoder turns an Entry into the bytes written to the output, including the
+// trailing newline.
+type Encoder interface {
+	Encode(entry *Entry) ([]byte, error)
+}
+
+// colorEncoder is implemented by encoders that can wrap their output in ANSI
+// color codes. Colors are only ever applied through this interface.
+type colorEncoder interface {
+	encodeColored(entry *Entry) ([]byte, error)
+}
+
+// EncoderConfig holds the settings shared by the built-in encoders.
+type EncoderConfig struct {
+	TimeLayout string              // Layout for timestamps; the encoder's default is used if empty
+	LevelNames map[LogLevel]string // Overrides for level names; levels not present use DEBUG, INFO, WARN, ERROR
+	Order      []string            // Order of the built-in keys (Key* constants except KeyFunction) for JSON and logfmt; missing keys follow in the default order
+	SortFields bool                // Sort structured fields by key instead of keeping insertion order
+}
+
+// levelName returns the configured name for level.
+func (c EncoderConfig) levelName(level LogLevel) string {
+	if name, ok := c.LevelNames[level]; ok {
+		return name
+	}
+
+	return level.String()
+}
+
+// timestamp formats t using the configured layout, or fallback if none is set.
+func (c EncoderConfig) timestamp(t time.Time, fallback string) string {
+	if c.TimeLayout != "" {
+		return t.Format(c.TimeLayout)
+	}
+
+	return t.Format(fallback)
+}
+
+// order returns the configured key order with any missing keys appended in
+// the default order.
+func (c EncoderConfig) order() []string {
+	if len(c.Order) == 0 {
+		return defaultOrder
+	}
+
+	order := make([]string, 0, len(defaultOrder))
+	for _, key := range c.Order {
+		if slices.Contains(defaultOrder, key) && !slices.Contains(order, key) {
+			order = append(order, key)
+		}
+	}
+	for _, key := range defaultOrder {
+		if !slices.Contains(order, key) {
+			order = append(order, key)
+		}
+	}
+
+	return order
+}
+
+// fields returns the entry's fields, sorted by key if configured.
+func (c EncoderConfig) fields(entry *Entry) []Field {
+	if !c.SortFields || len(entry.Fields) < 2 {
+		return entry.Fields
+	}
+
+	fields := slices.Clone(entry.Fields)
+	slices.SortStableFunc(fields, func(a, b Field) int {
+		return strings.Compare(a.Key, b.Key)
+	})
+
+	return fields
+}
+
+// TextEncoder writes entries in the human-readable format
+// "[timestamp] [LEVEL] prefix: message key=value ...". The caller, if any,
+// is written as caller= and function= pairs before the fields, and the stack
+// trace on the lines following the entry. It is the only encoder that
+// supports colored output.
+type TextEncoder struct {
+	config EncoderConfig
+}
+
+// NewTextEncoder creates a TextEncoder with the given configuration.
+// EncoderConfig.Order is ignored since the text layout is fixed.
+func NewTextEncoder(config EncoderConfig) *TextEncoder {
+	return &TextEncoder{config: config}
+}
+
+// Encode formats the entry without colors.
+func (e *TextEncoder) Encode(entry *Entry) ([]byte, error) {
+	return e.encode(entry, ""), nil
+}
+
+// encodeColored formats the entry wrapped in the color of its level.
+func (e *TextEncoder) encodeColored(entry *Entry) ([]byte, error) {
+	return e.encode(entry, levelColor(entry.Level)), nil
+}
+
+func (e *TextEncoder) encode(entry *Entry, color string) []byte {
+	var buf bytes.Buffer
+	buf.WriteString(color)
+	buf.WriteString("[")
+	buf.WriteString(e.config.timestamp(entry.Time, DefaultTextTimeLayout))
+	buf.WriteString("] [")
+	buf.WriteString(e.config.levelName(entry.Level))
+	buf.WriteString("] ")
+	buf.WriteString(entry.Prefix)
+	buf.WriteString(": ")
+	buf.WriteString(entry.Message)
+	if entry.Caller != nil {
+		buf.WriteString(" ")
+		buf.WriteString(Field{Key: KeyCaller, Value: entry.Caller.String()}.String())
+		buf.WriteString(" ")
+		buf.WriteString(Field{Key: KeyFunction, Value: entry.Caller.Function}.String())
+	}
+	for _, f := range e.config.fields(entry) {
+		buf.WriteString(" ")
+		buf.WriteString(f.String())
+	}
+	if entry.Stack != "" {
+		buf.WriteString("\n")
+		buf.WriteString(entry.Stack)
+	}
+	if color != "" {
+		buf.WriteString(ColorReset)
+	}
+	buf.WriteString("\n")
+
+	return buf.Bytes()
+}
+
+// JSONEncoder writes each entry as a single-line JSON object.
+type JSONEncoder struct {
+	config EncoderConfig
+}
+
+// NewJSONEncoder creates a JSONEncoder with the given configuration.
+func NewJSONEncoder(config EncoderConfig) *JSONEncoder {
+	return &JSONEncoder{config: config}
+}
+
+// Encode formats the entry as a JSON object followed by a newline.
+// Field values that cannot be marshaled are written as strings.
+func (e *JSONEncoder) Encode(entry *Entry) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString("{")
+	first := true
+	writeKey := func(key string) {
+		if !first {
+			buf.WriteString(",")
+		}
+		first = false
+		writeJSONString(&buf, key)
+		buf.WriteString(":")
+	}
+
+	for _, key := range e.config.order() {
+		switch key {
+		case KeyTime:
+			writeKey(KeyTime)
+			writeJSONString(&buf, e.config.timestamp(entry.Time, DefaultTimeLayout))
+		case KeyLevel:
+			writeKey(KeyLevel)
+			writeJSONString(&buf, e.config.levelName(entry.Level))
+		case KeyPrefix:
+			if entry.Prefix != "" {
+				writeKey(KeyPrefix)
+				writeJSONString(&buf, entry.Prefix)
+			}
+		case KeyMessage:
+			writeKey(KeyMessage)
+			writeJSONString(&buf, entry.Message)
+		case KeyCaller:
+			if entry.Caller != nil {
+				writeKey(KeyCaller)
+				writeJSONString(&buf, entry.Caller.String())
+				writeKey(KeyFunction)
+				writeJSONString(&buf, entry.Caller.Function)
+			}
+		case KeyFields:
+			for _, f := range e.config.fields(entry) {
+				writeKey(f.Key)
+				writeJSONValue(&buf, f.Value)
+			}
+		case KeyStack:
+			if entry.Stack != "" {
+				writeKey(KeyStack)
+				writeJSONString(&buf, entry.Stack)
+			}
+		}
+	}
+	buf.WriteString("}\n")
+
+	return buf.Bytes(), nil
+}
+
+// writeJSONString writes s as a JSON string.
+func writeJSONString(buf *bytes.Buffer, s string) {
+	b, _ := json.Marshal(s) // marshaling a string cannot fail
+	buf.Write(b)
+}
+
+// writeJSONValue writes v as JSON, falling back to its string form.
+func writeJSONValue(buf *bytes.Buffer, v any) {
+	switch val := v.(type) {
+	case error:
+		writeJSONString(buf, val.Error())
+
+		return
+	case fmt.Stringer:
+		if _, ok := v.(json.Marshaler); !ok {
+			writeJSONString(buf, val.String())
+
+			return
+		}
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		writeJSONString(buf, fmt.Sprint(v))
+
+		return
+	}
+	buf.Write(b)
+}
+
+// LogfmtEncoder writes each entry as a line of space-separated key=value pairs.
+type LogfmtEncoder struct {
+	config EncoderConfig
+}
+
+// NewLogfmtEncoder creates a LogfmtEncoder with the given configuration.
+func NewLogfmtEncoder(config EncoderConfig) *LogfmtEncoder {
+	return &LogfmtEncoder{config: config}
+}
+
+// Encode formats the entry as logfmt followed by a newline.
+func (e *LogfmtEncoder) Encode(entry *Entry) ([]byte, error) {
+	var buf bytes.Buffer
+	writePair := func(f Field) {
+		if buf.Len() > 0 {
+			buf.WriteString(" ")
+		}
+		buf.WriteString(f.String())
+	}
+
+	for _, key := range e.config.order() {
+		switch key {
+		case KeyTime:
+			writePair(Field{Key: KeyTime, Value: e.config.timestamp(entry.Time, DefaultTimeLayout)})
+		case KeyLevel:
+			writePair(Field{Key: KeyLevel, Value: e.config.levelName(entry.Level)})
+		case KeyPrefix:
+			if entry.Prefix != "" {
+				writePair(Field{Key: KeyPrefix, Value: entry.Prefix})
+			}
+		case KeyMessage:
+			writePair(Field{Key: KeyMessage, Value: entry.Message})
+		case KeyCaller:
+			if entry.Caller != nil {
+				writePair(Field{Key: KeyCaller, Value: entry.Caller.String()})
+				writePair(Field{Key: KeyFunction, Value: entry.Caller.Function})
+			}
+		case KeyFields:
+			for _, f := range e.config.fields(entry) {
+				writePair(f)
+			}
+		case KeyStack:
+			if entry.Stack != "" {
+				writePair(Field{Key: KeyStack, Value: entry.Stack})
+			}
+		}
+	}
+	buf.WriteString("\n")
+
+	return buf.Bytes(), nil
+}
+
+// levelColor returns the ANSI color code of level.
+func levelColor(level LogLevel) string {
+	switch level {
+	case DEBUG:
+		return ColorGreen
+	case INFO:
+		return ColorBlue
+	case WARN:
+		return ColorYellow
+	case ERROR:
+		return ColorRed
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,183 @@
+package logging_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kashifkhan0771/utils/logging"
+)
+
+var testTime = time.Date(2025, 1, 9, 12, 34, 56, 0, time.UTC)
+
+func testEntry() *logging.Entry {
+	return &logging.Entry{
+		Time:    testTime,
+		Level:   logging.WARN,
+		Prefix:  "MyApp",
+		Message: "disk almost full",
+		Fields: []logging.Field{
+			{Key: "mount", Value: "/var"},
+			{Key: "used", Value: 97.5},
+			{Key: "err", Value: errors.New("no space")},
+		},
+	}
+}
+
+func TestEncoders(t *testing.T) {
+	tests := []struct {
+		name    string
+		encoder logging.Encoder
+		want    string
+	}{
+		{
+			name:    "success - text encoder",
+			encoder: logging.NewTextEncoder(logging.EncoderConfig{}),
+			want:    "[2025-01-09 12:34:56] [WARN] MyApp: disk almost full mount=/var used=97.5 err=\"no space\"\n",
+		},
+		{
+			name:    "success - json encoder",
+			encoder: logging.NewJSONEncoder(logging.EncoderConfig{}),
+			want:    `{"time":"2025-01-09T12:34:56Z","level":"WARN","prefix":"MyApp","msg":"disk almost full","mount":"/var","used":97.5,"err":"no space"}` + "\n",
+		},
+		{
+			name:    "success - logfmt encoder",
+			encoder: logging.NewLogfmtEncoder(logging.EncoderConfig{}),
+			want:    "time=2025-01-09T12:34:56Z level=WARN prefix=MyApp msg=\"disk almost full\" mount=/var used=97.5 err=\"no space\"\n",
+		},
+		{
+			name: "success - custom time layout and level names",
+			encoder: logging.NewTextEncoder(logging.EncoderConfig{
+				TimeLayout: time.Kitchen,
+				LevelNames: map[logging.LogLevel]string{logging.WARN: "WARNING"},
+			}),
+			want: "[12:34PM] [WARNING] MyApp: disk almost full mount=/var used=97.5 err=\"no space\"\n",
+		},
+		{
+			name: "success - custom key order and sorted fields",
+			encoder: logging.NewLogfmtEncoder(logging.EncoderConfig{
+				Order:      []string{logging.KeyLevel, logging.KeyMessage, logging.KeyFields},
+				SortFields: true,
+			}),
+			want: "level=WARN msg=\"disk almost full\" err=\"no space\" mount=/var used=97.5 time=2025-01-09T12:34:56Z prefix=MyApp\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.encoder.Encode(testEntry())
+			if err != nil {
+				t.Fatalf("Encode() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Encode() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJSONEncoderOutputIsValidJSON(t *testing.T) {
+	entry := testEntry()
+	entry.Prefix = ""
+	entry.Message = "quotes \" and\nnewlines"
+	entry.Fields = append(entry.Fields,
+		logging.Field{Key: "duration", Value: 1500 * time.Millisecond},
+		logging.Field{Key: "unsupported", Value: make(chan int)},
+	)
+
+	got, err := logging.NewJSONEncoder(logging.EncoderConfig{}).Encode(entry)
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+
+	var decoded map[string]any
+	if err := json.Unmarshal(got, &decoded); err != nil {
+		t.Fatalf("Expected valid JSON, got %q: %v", got, err)
+	}
+	if _, ok := decoded["prefix"]; ok {
+		t.Errorf("Expected empty prefix to be omitted, got %v", decoded)
+	}
+	if decoded["msg"] != entry.Message {
+		t.Errorf("Expected msg %q, got %v", entry.Message, decoded["msg"])
+	}
+	if decoded["duration"] != "1.5s" {
+		t.Errorf("Expected duration to use its String form, got %v", decoded["duration"])
+	}
+	if _, ok := decoded["unsupported"].(string); !ok {
+		t.Errorf("Expected unsupported value to fall back to a string, got %v", decoded["unsupported"])
+	}
+}
+
+func TestLoggerSetEncoder(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	logger := logging.NewLogger("MyApp", logging.INFO, buffer)
+	logger.SetEncoder(logging.NewJSONEncoder(logging.EncoderConfig{}))
+
+	logger.Infow("started", "port", 8080)
+
+	output := buffer.String()
+	if strings.Contains(output, "\033[") {
+		t.Errorf("Expected no color codes in JSON output, got: %q", output)
+	}
+
+	var decoded map[string]any
+	if err := json.Unmarshal([]byte(output), &decoded); err != nil {
+		t.Fatalf("Expected a JSON line, got %q: %v", output, err)
+	}
+	if decoded["level"] != "INFO" || decoded["msg"] != "started" || decoded["port"] != float64(8080) {
+		t.Errorf("Unexpected JSON output: %v", decoded)
+	}
+}
+
+func TestLoggerColors(t *testing.T) {
+	tests := []struct {
+		name       string
+		colors     bool
+		encoder    logging.Encoder
+		wantColors bool
+	}{
+		{
+			name:       "success - default text encoder is colored",
+			colors:     true,
+			wantColors: true,
+		},
+		{
+			name:       "success - colors can be disabled",
+			colors:     false,
+			wantColors: false,
+		},
+		{
+			name:       "success - custom text encoder is colored",
+			colors:     true,
+			encoder:    logging.NewTextEncoder(logging.EncoderConfig{}),
+			wantColors: true,
+		},
+		{
+			name:       "success - logfmt encoder is never colored",
+			colors:     true,
+			encoder:    logging.NewLogfmtEncoder(logging.EncoderConfig{}),
+			wantColors: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buffer := &bytes.Buffer{}
+			logger := logging.NewLogger("Test", logging.INFO, buffer)
+			logger.SetColors(tt.colors)
+			if tt.encoder != nil {
+				logger.SetEncoder(tt.encoder)
+			}
+
+			logger.Error("boom")
+
+			output := buffer.String()
+			if got := strings.HasPrefix(output, logging.ColorRed); got != tt.wantColors {
+				t.Errorf("Expected colors = %v, got output: %q", tt.wantColors, output)
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package logging
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// badKey is used as the key for arguments that cannot be interpreted as a
+// key-value pair (a non-string key, or a trailing key without a value).
+const badKey = "!BADKEY"
+
+// Field is a structured key-value pair attached to a log entry.
+type Field struct {
+	Key   string // Name of the field
+	Value any    // Value of the field
+}
+
+// String returns the field in key=value form.
+func (f Field) String() string {
+	return f.Key + "=" + formatValue(f.Value)
+}
+
+// toFields converts a list of alternating keys and values into fields.
+// Arguments that are already a Field are used as-is. A non-string key, or a
+// trailing key without a value, is stored under the key "!BADKEY".
+func toFields(args []any) []Field {
+	if len(args) == 0 {
+		return nil
+	}
+
+	fields := make([]Field, 0, (len(args)+1)/2)
+	for i := 0; i < len(args); i++ {
+		switch arg := args[i].(type) {
+		case Field:
+			fields = append(fields, arg)
+		case string:
+			if i+1 >= len(args) {
+				fields = append(fields, Field{Key: badKey, Value: arg})
+
+				continue
+			}
+			fields = append(fields, Field{Key: arg, Value: args[i+1]})
+			i++
+		default:
+			fields = append(fields, Field{Key: badKey, Value: arg})
+		}
+	}
+
+	return fields
+}
+
+// formatValue renders a field value as text, quoting it if it contains
+// whitespace, quotes or '=' so that key=value output stays parseable.
+func formatValue(v any) string {
+	s := valueString(v)
+	if s == "" || strings.ContainsAny(s, " \t\r\n\"=") {
+		return strconv.Quote(s)
+	}
+
+	return s
+}
+
+// valueString renders a field value as unquoted text.
+func valueString(v any) string {
+	switch val := v.(type) {
+	case nil:
+		return "<nil>"
+	case string:
+		return val
+	case error:
+		return val.Error()
+	case fmt.Stringer:
+		return val.String()
+	default:
+		return fmt.Sprint(val)
+	}
+}
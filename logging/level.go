@@ -0,0 +1,163 @@
+package logging
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// String returns the name of the level: DEBUG, INFO, WARN or ERROR.
+// Unknown levels are rendered as LEVEL(n).
+func (l LogLevel) String() string {
+	switch l {
+	case DEBUG:
+		return "DEBUG"
+	case INFO:
+		return "INFO"
+	case WARN:
+		return "WARN"
+	case ERROR:
+		return "ERROR"
+	default:
+		return fmt.Sprintf("LEVEL(%d)", int(l))
+	}
+}
+
+// ParseLevel converts a level name into a LogLevel. Matching is
+// case-insensitive and ignores surrounding whitespace; "warning" is accepted
+// as an alias for WARN.
+//
+// Parameters:
+//   - name: The level name, e.g. "debug" or "WARN".
+//
+// Returns:
+//
+//	The matching LogLevel, or an error if the name is unknown.
+func ParseLevel(name string) (LogLevel, error) {
+	switch strings.ToUpper(strings.TrimSpace(name)) {
+	case "DEBUG":
+		return DEBUG, nil
+	case "INFO":
+		return INFO, nil
+	case "WARN", "WARNING":
+		return WARN, nil
+	case "ERROR":
+		return ERROR, nil
+	default:
+		return DEBUG, fmt.Errorf("unknown log level %q", name)
+	}
+}
+
+// MarshalText implements encoding.TextMarshaler using the level name.
+func (l LogLevel) MarshalText() ([]byte, error) {
+	return []byte(l.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler using ParseLevel.
+func (l *LogLevel) UnmarshalText(text []byte) error {
+	level, err := ParseLevel(string(text))
+	if err != nil {
+		return err
+	}
+	*l = level
+
+	return nil
+}
+
+// Level returns the minimum level of the logger. A child logger that follows
+// its parent returns the parent's level.
+func (l *Logger) Level() LogLevel {
+	for l.parent != nil && l.followParent.Load() {
+		l = l.parent
+	}
+
+	return LogLevel(l.level.Load())
+}
+
+// SetLevel atomically changes the minimum level of the logger. It is safe to
+// call while other goroutines are logging. On a child logger, it stops
+// following the parent's level.
+//
+// Parameters:
+//   - level: The new minimum log level.
+func (l *Logger) SetLevel(level LogLevel) {
+	l.level.Store(int32(level))
+	l.followParent.Store(false)
+}
+
+// FollowParentLevel controls whether a child logger created with With uses
+// its parent's level. Children follow their parent by default. It has no
+// effect on loggers without a parent.
+//
+// Parameters:
+//   - follow: Whether to use the parent's level.
+func (l *Logger) FollowParentLevel(follow bool) {
+	if follow {
+		l.followParent.Store(true)
+
+		return
+	}
+
+	// Keep the level currently in effect when detaching
+	l.level.Store(int32(l.Level()))
+	l.followParent.Store(false)
+}
+
+// levelPayload is the JSON body used by the level handler.
+type levelPayload struct {
+	Level LogLevel `json:"level"`
+}
+
+// LevelHandler returns an http.Handler for viewing and changing the logger's
+// level at runtime. GET responds with {"level":"INFO"}; PUT accepts the same
+// body (level names are case-insensitive), applies it with SetLevel and
+// responds with the new level. Other methods get 405 Method Not Allowed.
+//
+// Parameters:
+//   - logger: The Logger whose level is exposed.
+//
+// Returns:
+//
+//	An http.Handler to mount on an admin endpoint.
+func LevelHandler(logger *Logger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPut:
+			var payload struct {
+				Level *LogLevel `json:"level"`
+			}
+			if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<10)).Decode(&payload); err != nil {
+				writeLevelError(w, fmt.Sprintf("invalid request body: %v", err))
+
+				return
+			}
+			if payload.Level == nil {
+				writeLevelError(w, `missing "level"`)
+
+				return
+			}
+			logger.SetLevel(*payload.Level)
+		default:
+			w.Header().Set("Allow", "GET, PUT")
+			writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+
+			return
+		}
+
+		writeJSON(w, http.StatusOK, levelPayload{Level: logger.Level()})
+	})
+}
+
+// writeLevelError writes a 400 Bad Request response with a JSON error message.
+func writeLevelError(w http.ResponseWriter, message string) {
+	writeJSON(w, http.StatusBadRequest, map[string]string{"error": message})
+}
+
+// writeJSON writes v as a JSON response with the given status code.
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,212 @@
+package logging_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/kashifkhan0771/utils/logging"
+)
+
+func TestParseLevel(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    logging.LogLevel
+		wantErr bool
+	}{
+		{input: "debug", want: logging.DEBUG},
+		{input: "INFO", want: logging.INFO},
+		{input: " Warn ", want: logging.WARN},
+		{input: "warning", want: logging.WARN},
+		{input: "error", want: logging.ERROR},
+		{input: "fatal", wantErr: true},
+		{input: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := logging.ParseLevel(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseLevel(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("ParseLevel(%q) = %v, want %v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLogLevelString(t *testing.T) {
+	tests := map[logging.LogLevel]string{
+		logging.DEBUG:        "DEBUG",
+		logging.INFO:         "INFO",
+		logging.WARN:         "WARN",
+		logging.ERROR:        "ERROR",
+		logging.LogLevel(42): "LEVEL(42)",
+	}
+	for level, want := range tests {
+		if got := level.String(); got != want {
+			t.Errorf("String() = %q, want %q", got, want)
+		}
+	}
+}
+
+func TestLoggerSetLevel(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	logger := logging.NewLogger("T", logging.INFO, buffer)
+
+	logger.Debug("hidden")
+	logger.SetLevel(logging.DEBUG)
+	logger.Debug("shown")
+
+	if logger.Level() != logging.DEBUG {
+		t.Errorf("Level() = %v, want DEBUG", logger.Level())
+	}
+	output := buffer.String()
+	if strings.Contains(output, "hidden") || !strings.Contains(output, "shown") {
+		t.Errorf("Unexpected output: %q", output)
+	}
+}
+
+func TestChildLoggerLevel(t *testing.T) {
+	parent := logging.NewLogger("T", logging.INFO, &bytes.Buffer{})
+	child := parent.With("k", "v")
+	grandchild := child.With("k2", "v2")
+
+	parent.SetLevel(logging.ERROR)
+	if child.Level() != logging.ERROR || grandchild.Level() != logging.ERROR {
+		t.Fatalf("Expected children to follow the parent, got %v and %v", child.Level(), grandchild.Level())
+	}
+
+	child.SetLevel(logging.DEBUG)
+	parent.SetLevel(logging.WARN)
+	if child.Level() != logging.DEBUG {
+		t.Errorf("Expected child with its own level to stay DEBUG, got %v", child.Level())
+	}
+	if grandchild.Level() != logging.DEBUG {
+		t.Errorf("Expected grandchild to follow the child, got %v", grandchild.Level())
+	}
+
+	child.FollowParentLevel(true)
+	if child.Level() != logging.WARN {
+		t.Errorf("Expected child to follow the parent again, got %v", child.Level())
+	}
+
+	child.FollowParentLevel(false)
+	parent.SetLevel(logging.DEBUG)
+	if child.Level() != logging.WARN {
+		t.Errorf("Expected detached child to keep WARN, got %v", child.Level())
+	}
+}
+
+func TestSetLevelConcurrent(t *testing.T) {
+	logger := logging.NewLogger("T", logging.INFO, &lockedBuffer{})
+	var wg sync.WaitGroup
+	for i := range 4 {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for range 100 {
+				logger.SetLevel(logging.LogLevel(i % 4))
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for range 100 {
+				logger.Info("message")
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestLevelHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		body       string
+		wantStatus int
+		wantLevel  logging.LogLevel
+		wantBody   string
+	}{
+		{
+			name:       "success - get current level",
+			method:     http.MethodGet,
+			wantStatus: http.StatusOK,
+			wantLevel:  logging.INFO,
+			wantBody:   `{"level":"INFO"}`,
+		},
+		{
+			name:       "success - put new level",
+			method:     http.MethodPut,
+			body:       `{"level":"debug"}`,
+			wantStatus: http.StatusOK,
+			wantLevel:  logging.DEBUG,
+			wantBody:   `{"level":"DEBUG"}`,
+		},
+		{
+			name:       "error - unknown level",
+			method:     http.MethodPut,
+			body:       `{"level":"verbose"}`,
+			wantStatus: http.StatusBadRequest,
+			wantLevel:  logging.INFO,
+		},
+		{
+			name:       "error - missing level",
+			method:     http.MethodPut,
+			body:       `{}`,
+			wantStatus: http.StatusBadRequest,
+			wantLevel:  logging.INFO,
+		},
+		{
+			name:       "error - method not allowed",
+			method:     http.MethodPost,
+			body:       `{"level":"debug"}`,
+			wantStatus: http.StatusMethodNotAllowed,
+			wantLevel:  logging.INFO,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := logging.NewLogger("T", logging.INFO, &bytes.Buffer{})
+			server := httptest.NewServer(logging.LevelHandler(logger))
+			defer server.Close()
+
+			req, err := http.NewRequestWithContext(t.Context(), tt.method, server.URL, strings.NewReader(tt.body))
+			if err != nil {
+				t.Fatalf("NewRequest() error = %v", err)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("Do() error = %v", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			if logger.Level() != tt.wantLevel {
+				t.Errorf("Level() = %v, want %v", logger.Level(), tt.wantLevel)
+			}
+
+			var body map[string]any
+			if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+				t.Fatalf("Expected JSON body: %v", err)
+			}
+			if tt.wantBody != "" {
+				var want map[string]any
+				_ = json.Unmarshal([]byte(tt.wantBody), &want)
+				if body["level"] != want["level"] {
+					t.Errorf("body = %v, want %v", body, want)
+				}
+			} else if _, ok := body["error"]; !ok {
+				t.Errorf("Expected an error message, got %v", body)
+			}
+		})
+	}
+}
@@ -1,13 +1,17 @@
 // Package logging provides a simple logging library with support for
-// multiple log levels, custom prefixes, colored output, and customizable
-// output streams.
+// multiple log levels, custom prefixes, colored output, structured fields,
+// and customizable output streams.
 package logging
 
 import (
 	"fmt"
 	"io"
+	"log/slog"
 	"os"
 	"regexp"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"time"
 )
 
@@ -31,20 +35,35 @@ const (
 	ERROR                 // ERROR is used for critical error messages.
 )
 
+// defaultEncoder is used by loggers that have no encoder configured.
+var defaultEncoder = NewTextEncoder(EncoderConfig{})
+
 // RedactionRule defines how to redact sensitive information
 type RedactionRule struct {
-	Pattern     *regexp.Regexp // Regex pattern to match sensitive data
-	Replacement string         // Replacement text for matched patterns
+	Pattern     *regexp.Regexp           // Regex pattern to match sensitive data; only groups named "secret" are replaced if it has any
+	Replacement string                   // Replacement text for matched patterns
+	Validate    func(secret string) bool // Optional check a match must pass to be redacted, e.g. a Luhn checksum
+	Mask        MaskMode                 // How the match is replaced; MaskPartial ignores Replacement
 }
 
 // Logger is a configurable logging instance that supports multiple log levels,
 // optional colored output, and custom prefixes for log messages.
 type Logger struct {
-	minLevel       LogLevel        // Minimum log level for messages to be logged
-	prefix         string          // Prefix to prepend to all log messages
-	output         io.Writer       // Output destination for log messages (e.g., os.Stdout)
-	disableColors  bool            // Flag to disable color codes (useful for testing or non-ANSI terminals)
-	redactionRules []RedactionRule // Rules for redacting sensitive information
+	level          atomic.Int32        // Minimum log level for messages to be logged
+	parent         *Logger             // Logger this one was derived from with With, if any
+	followParent   atomic.Bool         // Use the parent's level instead of level
+	prefix         string              // Prefix to prepend to all log messages
+	disableColors  bool                // Flag to disable color codes (useful for testing or non-ANSI terminals)
+	redactionRules []RedactionRule     // Rules for redacting sensitive information
+	redactionKeys  map[string]MaskMode // Lowercased field keys whose values are always redacted
+	fields         []Field             // Structured fields attached to every log message
+	encoder        Encoder             // Encoder for log entries; the default text encoder is used if nil
+	handler        slog.Handler        // When set, entries are also handed to this slog.Handler
+	addCaller      bool                // Annotate entries with the location of the logging call
+	callerSkip     int                 // Extra frames to skip when locating the caller
+	addStack       bool                // Attach stack traces to entries at or above stackLevel
+	stackLevel     LogLevel            // Minimum level of entries that get a stack trace
+	out            *outputs            // Output sinks and error handler; shared with child loggers
 }
 
 // NewLogger creates and returns a new Logger instance with the specified prefix,
@@ -64,16 +83,81 @@ func NewLogger(prefix string, minLevel LogLevel, output io.Writer) *Logger {
 		output = os.Stdout // Default to standard output
 	}
 
-	return &Logger{
-		minLevel: minLevel,
-		prefix:   prefix,
-		output:   output,
+	return newLogger(prefix, minLevel, newOutputs(Sink{Writer: output}))
+}
+
+// newLogger creates a Logger writing to out.
+func newLogger(prefix string, minLevel LogLevel, out *outputs) *Logger {
+	l := &Logger{prefix: prefix, out: out}
+	l.level.Store(int32(minLevel))
+
+	return l
+}
+
+// With returns a child logger that attaches the given fields to every message
+// it logs. The child keeps the parent's prefix, encoder, color setting and
+// redaction rules, shares the parent's sinks, and follows the parent's level
+// until SetLevel is called on the child.
+//
+// Parameters:
+//   - args: Alternating keys and values (e.g., "user_id", 42), or Field values.
+//
+// Returns:
+//
+//	A pointer to a new Logger instance.
+func (l *Logger) With(args ...any) *Logger {
+	child := l.clone()
+	child.fields = append(child.fields, toFields(args)...)
+
+	return child
+}
+
+// clone returns a copy of the logger that can be modified without affecting l.
+// The copy follows l's level until its own level is set.
+func (l *Logger) clone() *Logger {
+	child := &Logger{
+		parent:         l,
+		prefix:         l.prefix,
+		disableColors:  l.disableColors,
+		redactionRules: l.redactionRules,
+		redactionKeys:  l.redactionKeys,
+		fields:         append([]Field(nil), l.fields...),
+		encoder:        l.encoder,
+		handler:        l.handler,
+		addCaller:      l.addCaller,
+		callerSkip:     l.callerSkip,
+		addStack:       l.addStack,
+		stackLevel:     l.stackLevel,
+		out:            l.out,
 	}
+	child.level.Store(int32(l.Level()))
+	child.followParent.Store(true)
+
+	return child
+}
+
+// SetEncoder configures the format in which log entries are written.
+// Passing nil restores the default text format.
+//
+// Parameters:
+//   - encoder: The Encoder to use, e.g. NewJSONEncoder or NewLogfmtEncoder.
+func (l *Logger) SetEncoder(encoder Encoder) {
+	l.encoder = encoder
+}
+
+// SetColors enables or disables ANSI color codes. Colors are only ever
+// applied by the text encoder; other encoders ignore this setting.
+//
+// Parameters:
+//   - enabled: Whether colored output should be used.
+func (l *Logger) SetColors(enabled bool) {
+	l.disableColors = !enabled
 }
 
 // SetRedactionRules configures the logger to redact sensitive information based on
 // the provided patterns. Each key represents a pattern to match, and the value is
-// the replacement text.
+// the replacement text. The rules replace those set by previous calls to
+// SetRedactionRules, SetRedactionRegex or AddRedactionRules.
 //
 // Parameters:
 //   - rules: A map where keys are patterns (e.g., "password=", "credit_card=") and
@@ -91,7 +175,8 @@ func (l *Logger) SetRedactionRules(rules map[string]string) {
 }
 
 // SetRedactionRegex allows users to define custom regex patterns for redaction.
-// This provides more flexibility than SetRedactionRules.
+// This provides more flexibility than SetRedactionRules. Like SetRedactionRules,
+// it replaces the rules configured so far.
 //
 // Parameters:
 //   - patterns: A map where keys are regex patterns and values are replacement strings.
@@ -117,63 +202,143 @@ func (l *Logger) SetRedactionRegex(patterns map[string]string) error {
 func (l *Logger) redact(message string) string {
 	redactedMessage := message
 	for _, rule := range l.redactionRules {
-		redactedMessage = rule.Pattern.ReplaceAllString(redactedMessage, rule.Replacement)
+		redactedMessage = rule.apply(redactedMessage)
 	}
 
 	return redactedMessage
 }
 
-// log handles the core logic of logging messages. It applies the appropriate
-// color coding (if enabled), formats the log message with a timestamp and prefix,
-// and writes it to the configured output destination.
+// redactField masks the value of a field whose key is redacted, then applies
+// the redaction rules to the field as if it were rendered in key=value form,
+// so that rules such as "password=" also match structured fields. The
+// original value is kept when nothing matches.
+func (l *Logger) redactField(f Field) Field {
+	if mode, ok := l.keyMask(f.Key); ok {
+		return Field{Key: f.Key, Value: mode.mask(valueString(f.Value), DefaultRedactionReplacement)}
+	}
+
+	pair := f.String()
+	redacted := l.redact(pair)
+	if redacted == pair {
+		return f
+	}
+
+	value, ok := strings.CutPrefix(redacted, f.Key+"=")
+	if !ok {
+		return Field{Key: f.Key, Value: redacted}
+	}
+	if unquoted, err := strconv.Unquote(value); err == nil && strings.HasPrefix(value, `"`) {
+		value = unquoted // quoted by formatValue, and quoted again when encoded
+	}
+
+	return Field{Key: f.Key, Value: value}
+}
+
+// log handles the core logic of logging messages. It builds an Entry with a
+// timestamp, prefix and structured fields, applies sampling, redaction and
+// duplicate suppression, and writes it to every sink whose level it meets.
 //
 // Parameters:
 //   - level: The LogLevel of the message being logged.
 //   - message: The actual log message to be recorded.
-func (l *Logger) log(level LogLevel, message string) {
-	if level < l.minLevel {
+//   - fields: Structured fields for this message, appended after the logger's own fields.
+func (l *Logger) log(level LogLevel, message string, fields []Field) {
+	l.logAt(time.Time{}, 0, level, message, fields)
+}
+
+// logAt is like log but uses t as the entry's timestamp, or the logger's
+// clock if t is zero, and pc as the location of the logging call, or the
+// caller of the logging method if pc is zero.
+func (l *Logger) logAt(t time.Time, pc uintptr, level LogLevel, message string, fields []Field) {
+	if level < l.Level() {
+		return
+	}
+
+	now := l.out.now()
+	if t.IsZero() {
+		t = now
+	}
+
+	if s := l.out.sampling(); s != nil && !s.allow(now, entryKey{level: level, message: message}) {
 		return
 	}
 
+	if len(l.fields) > 0 {
+		fields = append(append(make([]Field, 0, len(l.fields)+len(fields)), l.fields...), fields...)
+	}
+
 	// Apply redaction rules
-	if len(l.redactionRules) > 0 {
+	if len(l.redactionRules) > 0 || len(l.redactionKeys) > 0 {
 		message = l.redact(message)
+		for i, f := range fields {
+			fields[i] = l.redactField(f)
+		}
+	}
+
+	entry := &Entry{
+		Time:    t,
+		Level:   level,
+		Prefix:  l.prefix,
+		Message: message,
+		Fields:  fields,
+	}
+
+	if stack := l.wantsStack(level); l.addCaller || stack {
+		pcs := l.capture(pc, stack)
+		if l.addCaller {
+			entry.Caller = callerOf(pcs)
+		}
+		if stack {
+			entry.Stack = l.redact(formatStack(pcs))
+		}
 	}
 
-	// Determine the color and level string for the log level
-	color := ""
-	levelStr := ""
-	switch level {
-	case INFO:
-		color = ColorBlue
-		levelStr = "INFO"
-	case DEBUG:
-		color = ColorGreen
-		levelStr = "DEBUG"
-	case WARN:
-		color = ColorYellow
-		levelStr = "WARN"
-	case ERROR:
-		color = ColorRed
-		levelStr = "ERROR"
+	format := l.format()
+	if !l.dedupe(entry, format, now) {
+		return
 	}
 
-	// Disable color if the flag is set
-	if l.disableColors {
-		color = ""
+	l.dispatch(entry, format)
+}
+
+// entryFormat is the encoder and color setting of a logger, captured when an
+// entry is logged so that later changes to the logger don't affect it.
+type entryFormat struct {
+	encoder Encoder // Encoder of the logger; the default text encoder is used if nil
+	colored bool    // Whether the logger has colors enabled
+}
+
+// format returns the current encoder and color setting of the logger.
+func (l *Logger) format() entryFormat {
+	return entryFormat{encoder: l.encoder, colored: !l.disableColors}
+}
+
+// writeEntry hands the entry to the slog.Handler, if any, and writes it to
+// the sinks in the given format.
+func (l *Logger) writeEntry(entry *Entry, format entryFormat) {
+	if l.handler != nil {
+		if err := l.handleSlog(entry); err != nil {
+			_, errorHandler := l.out.snapshot()
+			reportError(errorHandler, err)
+		}
 	}
 
-	// Format the timestamp for the log message
-	timestamp := time.Now().Format("2006-01-02 15:04:05")
+	l.writeSinks(entry, format)
+}
 
-	// Construct the formatted log message
-	logMessage := fmt.Sprintf("%s[%s] [%s] %s: %s%s\n", color, timestamp, levelStr, l.prefix, message, ColorReset)
+// encode renders the entry with encoder, or with the default text encoder if
+// encoder is nil. Colors are applied only when requested and the encoder
+// supports them.
+func encode(encoder Encoder, entry *Entry, colored bool) ([]byte, error) {
+	if encoder == nil {
+		encoder = defaultEncoder
+	}
 
-	// Write the log message to the configured output
-	_, err := fmt.Fprint(l.output, logMessage)
-	if err != nil {
-		_, _ = fmt.Fprintf(os.Stderr, "Failed to write log: %v\n", err)
+	if ce, ok := encoder.(colorEncoder); ok && colored {
+		return ce.encodeColored(entry)
 	}
+
+	return encoder.Encode(entry)
 }
 
 // Info logs a message at the INFO level.
@@ -181,7 +346,7 @@ func (l *Logger) log(level LogLevel, message string) {
 // Parameters:
 //   - message: The informational message to log.
 func (l *Logger) Info(message string) {
-	l.log(INFO, message)
+	l.log(INFO, message, nil)
 }
 
 // Debug logs a message at the DEBUG level.
@@ -189,7 +354,7 @@ func (l *Logger) Info(message string) {
 // Parameters:
 //   - message: The debug message to log.
 func (l *Logger) Debug(message string) {
-	l.log(DEBUG, message)
+	l.log(DEBUG, message, nil)
 }
 
 // Warn logs a message at the WARN level.
@@ -197,7 +362,7 @@ func (l *Logger) Debug(message string) {
 // Parameters:
 //   - message: The warning message to log.
 func (l *Logger) Warn(message string) {
-	l.log(WARN, message)
+	l.log(WARN, message, nil)
 }
 
 // Error logs a message at the ERROR level.
@@ -205,5 +370,41 @@ func (l *Logger) Warn(message string) {
 // Parameters:
 //   - message: The error message to log.
 func (l *Logger) Error(message string) {
-	l.log(ERROR, message)
+	l.log(ERROR, message, nil)
+}
+
+// Infow logs a message at the INFO level with structured key-value fields.
+//
+// Parameters:
+//   - message: The informational message to log.
+//   - args: Alternating keys and values (e.g., "user_id", 42), or Field values.
+func (l *Logger) Infow(message string, args ...any) {
+	l.log(INFO, message, toFields(args))
+}
+
+// Debugw logs a message at the DEBUG level with structured key-value fields.
+//
+// Parameters:
+//   - message: The debug message to log.
+//   - args: Alternating keys and values (e.g., "user_id", 42), or Field values.
+func (l *Logger) Debugw(message string, args ...any) {
+	l.log(DEBUG, message, toFields(args))
+}
+
+// Warnw logs a message at the WARN level with structured key-value fields.
+//
+// Parameters:
+//   - message: The warning message to log.
+//   - args: Alternating keys and values (e.g., "user_id", 42), or Field values.
+func (l *Logger) Warnw(message string, args ...any) {
+	l.log(WARN, message, toFields(args))
+}
+
+// Errorw logs a message at the ERROR level with structured key-value fields.
+//
+// Parameters:
+//   - message: The error message to log.
+//   - args: Alternating keys and values (e.g., "user_id", 42), or Field values.
+func (l *Logger) Errorw(message string, args ...any) {
+	l.log(ERROR, message, toFields(args))
 }
@@ -236,6 +236,109 @@ func TestLoggerRedactionRegex(t *testing.T) {
 	}
 }
 
+func TestLoggerStructuredFields(t *testing.T) {
+	tests := []struct {
+		name           string
+		log            func(l *logging.Logger)
+		wantContains   []string
+		wantNotContain []string
+	}{
+		{
+			name: "success - key-value pairs are appended",
+			log: func(l *logging.Logger) {
+				l.Infow("user logged in", "user_id", 42, "admin", true)
+			},
+			wantContains: []string{"[INFO] Test: user logged in user_id=42 admin=true"},
+		},
+		{
+			name: "success - values with spaces are quoted",
+			log: func(l *logging.Logger) {
+				l.Warnw("slow query", "query", "SELECT * FROM users")
+			},
+			wantContains: []string{`query="SELECT * FROM users"`},
+		},
+		{
+			name: "success - Field values are accepted",
+			log: func(l *logging.Logger) {
+				l.Errorw("failed", logging.Field{Key: "attempt", Value: 3})
+			},
+			wantContains: []string{"[ERROR] Test: failed attempt=3"},
+		},
+		{
+			name: "success - non-string key and missing value use !BADKEY",
+			log: func(l *logging.Logger) {
+				l.Debugw("odd args", 7, "dangling")
+			},
+			wantContains: []string{"!BADKEY=7", "!BADKEY=dangling"},
+		},
+		{
+			name: "success - child logger fields come before call fields",
+			log: func(l *logging.Logger) {
+				l.With("request_id", "abc").Infow("handled", "status", 200)
+			},
+			wantContains: []string{"handled request_id=abc status=200"},
+		},
+		{
+			name: "success - child logger fields on plain methods",
+			log: func(l *logging.Logger) {
+				l.With("component", "db").With("shard", 2).Info("connected")
+			},
+			wantContains: []string{"connected component=db shard=2"},
+		},
+		{
+			name: "success - parent is not affected by child fields",
+			log: func(l *logging.Logger) {
+				_ = l.With("component", "db")
+				l.Info("parent message")
+			},
+			wantContains:   []string{"parent message"},
+			wantNotContain: []string{"component=db"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buffer := &bytes.Buffer{}
+			logger := logging.NewLogger("Test", logging.DEBUG, buffer)
+
+			tt.log(logger)
+
+			output := buffer.String()
+			for _, want := range tt.wantContains {
+				if !strings.Contains(output, want) {
+					t.Errorf("Expected output to contain '%v', got: %v", want, output)
+				}
+			}
+			for _, notWant := range tt.wantNotContain {
+				if strings.Contains(output, notWant) {
+					t.Errorf("Expected output NOT to contain '%v', got: %v", notWant, output)
+				}
+			}
+		})
+	}
+}
+
+func TestLoggerWithKeepsParentConfiguration(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	logger := logging.NewLogger("Parent", logging.WARN, buffer)
+	logger.SetRedactionRules(map[string]string{"password=": "***REDACTED***"})
+
+	child := logger.With("user", "bob")
+	child.Info("filtered out")
+	if buffer.Len() != 0 {
+		t.Fatalf("Expected child to keep parent's minimum level, got: %v", buffer.String())
+	}
+
+	child.Warnw("login failed", "password", "hunter2")
+	output := buffer.String()
+	if !strings.Contains(output, "Parent: login failed user=bob password=***REDACTED***") {
+		t.Errorf("Expected prefix, fields and redaction to carry through, got: %v", output)
+	}
+	if strings.Contains(output, "hunter2") {
+		t.Errorf("Expected field value to be redacted, got: %v", output)
+	}
+}
+
 // ================================================================================
 // ### BENCHMARKS
 // ================================================================================
@@ -259,3 +362,11 @@ func BenchmarkLoggerWithRedaction(b *testing.B) {
 		logger.Info("User email=user@example.com logged in with password:secret123")
 	}
 }
+
+func BenchmarkLoggerWithFields(b *testing.B) {
+	logger := logging.NewLogger("Test", logging.INFO, io.Discard).With("service", "api")
+	b.ReportAllocs()
+	for b.Loop() {
+		logger.Infow("This is an info message", "user_id", 42, "path", "/health")
+	}
+}
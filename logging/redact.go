@@ -0,0 +1,236 @@
+package logging
+
+import (
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// DefaultRedactionReplacement is the replacement used by the built-in rules
+// and by key-based redaction.
+const DefaultRedactionReplacement = "[REDACTED]"
+
+// secretGroup is the name of the capture group that limits redaction to part
+// of a match, e.g. the token after "Bearer ".
+const secretGroup = "secret"
+
+// partialMaskVisible is the number of trailing characters kept by MaskPartial.
+const partialMaskVisible = 4
+
+// MaskMode selects how a secret is replaced.
+type MaskMode int
+
+// Supported mask modes.
+const (
+	MaskFull    MaskMode = iota // MaskFull replaces the secret with the rule's replacement.
+	MaskPartial                 // MaskPartial keeps the last 4 characters and replaces the rest with '*'.
+)
+
+// mask hides secret according to the mode.
+func (m MaskMode) mask(secret, replacement string) string {
+	if m != MaskPartial {
+		return replacement
+	}
+
+	n := utf8.RuneCountInString(secret)
+	if n <= partialMaskVisible {
+		return strings.Repeat("*", n)
+	}
+
+	runes := []rune(secret)
+
+	return strings.Repeat("*", n-partialMaskVisible) + string(runes[n-partialMaskVisible:])
+}
+
+// apply redacts every match of the rule in s. Plain rules use
+// regexp.ReplaceAllString, so their replacement may refer to submatches
+// with $1 or ${name}.
+func (r RedactionRule) apply(s string) string {
+	if r.Pattern == nil {
+		return s
+	}
+
+	var groups []int
+	for i, name := range r.Pattern.SubexpNames() {
+		if name == secretGroup {
+			groups = append(groups, i)
+		}
+	}
+	if len(groups) == 0 && r.Validate == nil && r.Mask == MaskFull {
+		return r.Pattern.ReplaceAllString(s, r.Replacement)
+	}
+
+	matches := r.Pattern.FindAllStringSubmatchIndex(s, -1)
+	if len(matches) == 0 {
+		return s
+	}
+
+	var b strings.Builder
+	last := 0
+	for _, m := range matches {
+		spans := [][2]int{{m[0], m[1]}}
+		if len(groups) > 0 {
+			spans = spans[:0]
+			for _, g := range groups {
+				if m[2*g] >= 0 {
+					spans = append(spans, [2]int{m[2*g], m[2*g+1]})
+				}
+			}
+		}
+
+		for _, span := range spans {
+			secret := s[span[0]:span[1]]
+			if span[0] < last || (r.Validate != nil && !r.Validate(secret)) {
+				continue
+			}
+			b.WriteString(s[last:span[0]])
+			b.WriteString(r.Mask.mask(secret, r.Replacement))
+			last = span[1]
+		}
+	}
+	b.WriteString(s[last:])
+
+	return b.String()
+}
+
+// AddRedactionRules adds rules to the ones already configured, unlike
+// SetRedactionRules and SetRedactionRegex which replace them. Rules are
+// applied in the order they were added, to the message, the stack trace and
+// every structured field rendered as key=value. If a rule's pattern has
+// capture groups named "secret", only their text is replaced.
+//
+// Parameters:
+//   - rules: The rules to add, e.g. the built-in JWTRedaction or CreditCardRedaction.
+func (l *Logger) AddRedactionRules(rules ...RedactionRule) {
+	l.redactionRules = append(slices.Clip(l.redactionRules), rules...)
+}
+
+// AddRedactionKeys masks the value of every structured field whose key
+// matches one of keys, whatever the value looks like. Keys are compared
+// case-insensitively, with both the full key and the part after the last
+// '.', so "password" also matches the slog group attribute "user.password".
+// Keys add to those already configured.
+//
+// Parameters:
+//   - mode: MaskFull replaces the value with DefaultRedactionReplacement; MaskPartial keeps its last 4 characters.
+//   - keys: The field names to redact, e.g. "password", "token", "authorization".
+func (l *Logger) AddRedactionKeys(mode MaskMode, keys ...string) {
+	redactionKeys := make(map[string]MaskMode, len(l.redactionKeys)+len(keys))
+	maps.Copy(redactionKeys, l.redactionKeys)
+	for _, key := range keys {
+		redactionKeys[strings.ToLower(key)] = mode
+	}
+
+	l.redactionKeys = redactionKeys
+}
+
+// keyMask returns the mask mode for a field key and whether the key is redacted.
+func (l *Logger) keyMask(key string) (MaskMode, bool) {
+	if len(l.redactionKeys) == 0 {
+		return MaskFull, false
+	}
+
+	key = strings.ToLower(key)
+	if mode, ok := l.redactionKeys[key]; ok {
+		return mode, true
+	}
+	if i := strings.LastIndexByte(key, '.'); i >= 0 {
+		mode, ok := l.redactionKeys[key[i+1:]]
+
+		return mode, ok
+	}
+
+	return MaskFull, false
+}
+
+// JWTRedaction returns a rule that replaces JSON Web Tokens with
+// DefaultRedactionReplacement.
+func JWTRedaction() RedactionRule {
+	return RedactionRule{
+		Pattern:     regexp.MustCompile(`\beyJ[A-Za-z0-9_-]+\.eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`),
+		Replacement: DefaultRedactionReplacement,
+	}
+}
+
+// AWSKeyRedaction returns a rule that replaces AWS access key IDs and secret
+// access keys assigned to a key named like aws_secret_access_key with
+// DefaultRedactionReplacement.
+func AWSKeyRedaction() RedactionRule {
+	return RedactionRule{
+		Pattern: regexp.MustCompile(`\b(?P<secret>(?:AKIA|ASIA|ABIA|ACCA)[A-Z0-9]{16})\b` +
+			`|(?i:aws_?secret_?access_?key)["']?\s*[:=]\s*["']?(?P<secret>[A-Za-z0-9/+]{40})(?:[^A-Za-z0-9/+]|$)`),
+		Replacement: DefaultRedactionReplacement,
+	}
+}
+
+// CreditCardRedaction returns a rule that replaces card numbers of 13 to 19
+// digits, optionally grouped with spaces or dashes, that pass the Luhn
+// checksum with DefaultRedactionReplacement.
+func CreditCardRedaction() RedactionRule {
+	return RedactionRule{
+		Pattern:     regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`),
+		Replacement: DefaultRedactionReplacement,
+		Validate:    luhnValid,
+	}
+}
+
+// EmailRedaction returns a rule that replaces email addresses with
+// DefaultRedactionReplacement.
+func EmailRedaction() RedactionRule {
+	return RedactionRule{
+		Pattern:     regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`),
+		Replacement: DefaultRedactionReplacement,
+	}
+}
+
+// BearerTokenRedaction returns a rule that replaces the token of a
+// "Bearer <token>" credential with DefaultRedactionReplacement, keeping the
+// "Bearer " scheme.
+func BearerTokenRedaction() RedactionRule {
+	return RedactionRule{
+		Pattern:     regexp.MustCompile(`(?i)\bbearer\s+(?P<secret>[A-Za-z0-9._~+/-]+=*)`),
+		Replacement: DefaultRedactionReplacement,
+	}
+}
+
+// DefaultRedactions returns all built-in rules: JWTs, AWS keys, credit card
+// numbers, email addresses and bearer tokens. JWTs are matched before bearer
+// tokens so that "Bearer <jwt>" keeps its scheme either way.
+func DefaultRedactions() []RedactionRule {
+	return []RedactionRule{
+		JWTRedaction(),
+		AWSKeyRedaction(),
+		CreditCardRedaction(),
+		EmailRedaction(),
+		BearerTokenRedaction(),
+	}
+}
+
+// luhnValid reports whether the digits in number pass the Luhn checksum.
+// Spaces and dashes are ignored.
+func luhnValid(number string) bool {
+	sum, digits := 0, 0
+	for i := len(number) - 1; i >= 0; i-- {
+		c := number[i]
+		if c == ' ' || c == '-' {
+			continue
+		}
+		if c < '0' || c > '9' {
+			return false
+		}
+
+		d := int(c - '0')
+		if digits%2 == 1 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		digits++
+	}
+
+	return digits >= 13 && sum%10 == 0
+}
@@ -0,0 +1,428 @@
+package logging
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// backupTimeFormat is the timestamp layout embedded in rotated file names.
+const backupTimeFormat = "2006-01-02T15-04-05.000"
+
+// compressSuffix is appended to the name of compressed backups.
+const compressSuffix = ".gz"
+
+// rotateRetryDelay is how long Write keeps using the current file after a
+// rotation failed before it tries to rotate again.
+const rotateRetryDelay = 30 * time.Second
+
+// RotationInterval defines how often a RotatingFile is rotated by time.
+type RotationInterval int
+
+// Supported rotation intervals.
+const (
+	RotateNever  RotationInterval = iota // RotateNever disables time-based rotation.
+	RotateHourly                         // RotateHourly rotates at the start of every hour.
+	RotateDaily                          // RotateDaily rotates at local midnight.
+)
+
+// RotateOptions configures when a RotatingFile is rotated and which backups are kept.
+type RotateOptions struct {
+	MaxSize    int64            // Maximum size in bytes before rotating; 0 disables size-based rotation
+	Interval   RotationInterval // Time-based rotation interval; RotateNever disables it
+	MaxBackups int              // Maximum number of backups to keep; 0 keeps all
+	MaxAge     time.Duration    // Maximum age of backups, based on the time in their name; 0 keeps all
+	Compress   bool             // Gzip backups after rotation
+}
+
+// RotatingFile is an io.WriteCloser that writes to a file and rotates it by
+// size and/or time. Rotated files are renamed to
+// "<name>-<timestamp><ext>" next to the original (e.g. app-2025-01-09T12-34-56.000.log),
+// optionally gzipped, and pruned according to MaxBackups and MaxAge.
+//
+// Compression and pruning run in a background goroutine so they never block
+// writers; errors during this cleanup are ignored and retried after the next
+// rotation. RotatingFile is safe for concurrent use.
+type RotatingFile struct {
+	mu           sync.Mutex
+	filename     string
+	opts         RotateOptions
+	file         *os.File
+	size         int64
+	nextRotation time.Time // Zero when time-based rotation is disabled
+	retryAfter   time.Time // No automatic rotation before this time, set after a rotation failed
+	closed       bool
+	now          func() time.Time // Time source, replaceable in tests
+	millCh       chan struct{}
+	millDone     chan struct{}
+}
+
+// NewRotatingFile opens (or creates) filename for appending and returns a
+// RotatingFile that can be used as the output of a Logger. Missing parent
+// directories are created.
+//
+// Parameters:
+//   - filename: Path of the active log file.
+//   - opts: Rotation and retention settings.
+//
+// Returns:
+//
+//	A pointer to a RotatingFile, or an error if the file cannot be opened.
+func NewRotatingFile(filename string, opts RotateOptions) (*RotatingFile, error) {
+	r := &RotatingFile{
+		filename: filename,
+		opts:     opts,
+		now:      time.Now,
+		millCh:   make(chan struct{}, 1),
+		millDone: make(chan struct{}),
+	}
+
+	if err := r.openExisting(); err != nil {
+		return nil, err
+	}
+
+	go r.millRun()
+
+	return r, nil
+}
+
+// Write writes p to the current file, rotating first if the write would
+// exceed MaxSize or the rotation interval has elapsed. A single write larger
+// than MaxSize is written to a fresh file rather than split.
+//
+// If the rotation fails, p is still written to the current file and the
+// rotation error is returned with the number of bytes written. Writes then
+// keep going to the current file for a while before rotating is tried again.
+func (r *RotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return 0, os.ErrClosed
+	}
+
+	now := r.now()
+	rotateByTime := !r.nextRotation.IsZero() && !now.Before(r.nextRotation)
+	rotateBySize := r.opts.MaxSize > 0 && r.size > 0 && r.size+int64(len(p)) > r.opts.MaxSize
+	var rotateErr error
+	if (rotateByTime || rotateBySize) && !now.Before(r.retryAfter) {
+		if rotateErr = r.rotate(now); rotateErr != nil {
+			r.retryAfter = now.Add(rotateRetryDelay)
+		}
+	}
+
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+	if err == nil {
+		err = rotateErr
+	}
+
+	return n, err
+}
+
+// Rotate renames the current file to a backup, opens a new file and closes
+// the old one. If the file no longer exists, a new one is created without a
+// backup. Rotate must not be combined with an external tool such as logrotate,
+// which would end up with an empty backup on every call; use Reopen instead.
+func (r *RotatingFile) Rotate() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return os.ErrClosed
+	}
+
+	return r.rotate(r.now())
+}
+
+// Reopen opens the file again for appending, creating it if needed, and
+// closes the previous one, without renaming anything. Call it after an
+// external tool such as logrotate moved the file away, e.g. from a SIGHUP
+// handler. If the file cannot be opened, the previous one stays open.
+func (r *RotatingFile) Reopen() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return os.ErrClosed
+	}
+
+	old := r.file
+	if err := r.openExisting(); err != nil {
+		return err
+	}
+	if err := old.Close(); err != nil {
+		return fmt.Errorf("failed to close log file: %w", err)
+	}
+
+	return nil
+}
+
+// Close closes the current file and waits for any pending compression and
+// pruning to finish. Writes after Close return os.ErrClosed.
+func (r *RotatingFile) Close() error {
+	r.mu.Lock()
+	if r.closed {
+		r.mu.Unlock()
+
+		return nil
+	}
+	r.closed = true
+	err := r.file.Close()
+	close(r.millCh)
+	r.mu.Unlock()
+
+	<-r.millDone
+
+	return err
+}
+
+// openExisting opens the log file for appending, creating it if needed.
+// Caller must hold the mutex or have exclusive access.
+func (r *RotatingFile) openExisting() error {
+	if err := os.MkdirAll(filepath.Dir(r.filename), 0o750); err != nil {
+		return fmt.Errorf("failed to create log directory: %w", err)
+	}
+
+	file, err := os.OpenFile(r.filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+
+		return fmt.Errorf("failed to stat log file: %w", err)
+	}
+
+	r.file = file
+	r.size = info.Size()
+	start := r.now()
+	if info.Size() > 0 {
+		// An existing file belongs to the period in which it was last written
+		start = info.ModTime()
+	}
+	r.nextRotation = r.periodEnd(start)
+
+	return nil
+}
+
+// rotate moves the current file to a backup and opens a new one.
+// If the rename or the open fails, the current file stays open so that
+// writes keep working and the next rotation can try again.
+// Caller must hold the mutex.
+func (r *RotatingFile) rotate(now time.Time) error {
+	err := os.Rename(r.filename, r.backupName(now))
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to rename log file: %w", err)
+	}
+
+	file, err := os.OpenFile(r.filename, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+
+	old := r.file
+	r.file = file
+	r.size = 0
+	r.nextRotation = r.periodEnd(now)
+
+	if err := old.Close(); err != nil {
+		return fmt.Errorf("failed to close log file: %w", err)
+	}
+
+	select {
+	case r.millCh <- struct{}{}:
+	default: // a cleanup is already pending
+	}
+
+	return nil
+}
+
+// periodEnd returns the time at which the rotation period containing t ends,
+// or the zero time if time-based rotation is disabled.
+func (r *RotatingFile) periodEnd(t time.Time) time.Time {
+	switch r.opts.Interval {
+	case RotateHourly:
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+	case RotateDaily:
+		return time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+	default:
+		return time.Time{}
+	}
+}
+
+// backupName returns an unused backup file name for a rotation at t.
+func (r *RotatingFile) backupName(t time.Time) string {
+	dir, prefix, ext := r.nameParts()
+	base := filepath.Join(dir, prefix+t.Format(backupTimeFormat))
+
+	name := base + ext
+	for i := 1; fileExists(name) || fileExists(name+compressSuffix); i++ {
+		name = fmt.Sprintf("%s-%d%s", base, i, ext)
+	}
+
+	return name
+}
+
+// nameParts splits the log file name into its directory, the backup prefix
+// ("<name>-") and its extension.
+func (r *RotatingFile) nameParts() (dir, prefix, ext string) {
+	dir = filepath.Dir(r.filename)
+	base := filepath.Base(r.filename)
+	ext = filepath.Ext(base)
+
+	return dir, strings.TrimSuffix(base, ext) + "-", ext
+}
+
+// backupFile describes a rotated file found on disk.
+type backupFile struct {
+	path       string
+	timestamp  time.Time
+	seq        int // Suffix added when several rotations share a timestamp
+	compressed bool
+}
+
+// millRun compresses and prunes backups each time a rotation signals it,
+// until the RotatingFile is closed.
+func (r *RotatingFile) millRun() {
+	defer close(r.millDone)
+
+	for range r.millCh {
+		r.mill()
+	}
+}
+
+// mill compresses uncompressed backups and removes those beyond MaxBackups or
+// older than MaxAge. Errors are ignored; the next run retries.
+func (r *RotatingFile) mill() {
+	backups, err := r.listBackups()
+	if err != nil {
+		return
+	}
+
+	var cutoff time.Time
+	if r.opts.MaxAge > 0 {
+		cutoff = r.now().Add(-r.opts.MaxAge)
+	}
+
+	for i, b := range backups {
+		expired := !cutoff.IsZero() && b.timestamp.Before(cutoff)
+		if expired || (r.opts.MaxBackups > 0 && i >= r.opts.MaxBackups) {
+			_ = os.Remove(b.path)
+
+			continue
+		}
+
+		if r.opts.Compress && !b.compressed {
+			_ = compressFile(b.path)
+		}
+	}
+}
+
+// listBackups returns the backups of this file, newest first.
+func (r *RotatingFile) listBackups() ([]backupFile, error) {
+	dir, prefix, ext := r.nameParts()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var backups []backupFile
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+
+		compressed := strings.HasSuffix(name, ext+compressSuffix)
+		if !compressed && !strings.HasSuffix(name, ext) {
+			continue
+		}
+
+		stamp := strings.TrimPrefix(name, prefix)
+		if len(stamp) < len(backupTimeFormat) {
+			continue
+		}
+		ts, err := time.ParseInLocation(backupTimeFormat, stamp[:len(backupTimeFormat)], time.Local)
+		if err != nil {
+			continue
+		}
+
+		var seq int
+		if rest := strings.TrimSuffix(strings.TrimSuffix(stamp[len(backupTimeFormat):], compressSuffix), ext); rest != "" {
+			if _, err := fmt.Sscanf(rest, "-%d", &seq); err != nil {
+				continue
+			}
+		}
+
+		backups = append(backups, backupFile{
+			path:       filepath.Join(dir, name),
+			timestamp:  ts,
+			seq:        seq,
+			compressed: compressed,
+		})
+	}
+
+	sort.SliceStable(backups, func(i, j int) bool {
+		if backups[i].timestamp.Equal(backups[j].timestamp) {
+			return backups[i].seq > backups[j].seq
+		}
+
+		return backups[i].timestamp.After(backups[j].timestamp)
+	})
+
+	return backups, nil
+}
+
+// compressFile gzips path to path+".gz" and removes the original.
+func compressFile(path string) (err error) {
+	src, err := os.Open(path) // #nosec G304 -- path comes from listing the log directory
+	if err != nil {
+		return err
+	}
+	defer func() { _ = src.Close() }()
+
+	dstPath := path + compressSuffix
+	dst, err := os.OpenFile(dstPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = os.Remove(dstPath)
+		}
+	}()
+
+	gz := gzip.NewWriter(dst)
+	if _, err = io.Copy(gz, src); err != nil {
+		_ = dst.Close()
+
+		return err
+	}
+	if err = gz.Close(); err != nil {
+		_ = dst.Close()
+
+		return err
+	}
+	if err = dst.Close(); err != nil {
+		return err
+	}
+	_ = src.Close()
+
+	return os.Remove(path)
+}
+
+// fileExists reports whether a file exists at path.
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+
+	return err == nil
+}
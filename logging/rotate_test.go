@@ -0,0 +1,401 @@
+package logging
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeNow returns a controllable time source for RotatingFile tests.
+func fakeNow(start time.Time) (func() time.Time, func(d time.Duration)) {
+	var mu sync.Mutex
+	now := start
+
+	return func() time.Time {
+			mu.Lock()
+			defer mu.Unlock()
+
+			return now
+		}, func(d time.Duration) {
+			mu.Lock()
+			defer mu.Unlock()
+			now = now.Add(d)
+		}
+}
+
+// listDir returns the sorted file names in dir.
+func listDir(t *testing.T, dir string) []string {
+	t.Helper()
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("ReadDir() error = %v", err)
+	}
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+func newTestRotatingFile(t *testing.T, opts RotateOptions, start time.Time) (*RotatingFile, func(time.Duration), string) {
+	t.Helper()
+
+	dir := t.TempDir()
+	r, err := NewRotatingFile(filepath.Join(dir, "app.log"), opts)
+	if err != nil {
+		t.Fatalf("NewRotatingFile() error = %v", err)
+	}
+	now, advance := fakeNow(start)
+	r.now = now
+	r.nextRotation = r.periodEnd(start)
+
+	return r, advance, dir
+}
+
+func TestRotatingFile_RotateBySize(t *testing.T) {
+	start := time.Date(2025, 1, 9, 12, 0, 0, 0, time.Local)
+	r, advance, dir := newTestRotatingFile(t, RotateOptions{MaxSize: 10}, start)
+
+	for _, line := range []string{"first\n", "second\n", "third\n"} {
+		if _, err := r.Write([]byte(line)); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+		advance(time.Second)
+	}
+	if err := r.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	want := []string{
+		"app-2025-01-09T12-00-01.000.log",
+		"app-2025-01-09T12-00-02.000.log",
+		"app.log",
+	}
+	if got := listDir(t, dir); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("files = %v, want %v", got, want)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "app.log"))
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if string(data) != "third\n" {
+		t.Errorf("active file = %q, want %q", data, "third\n")
+	}
+}
+
+func TestRotatingFile_RotateByTime(t *testing.T) {
+	tests := []struct {
+		name     string
+		interval RotationInterval
+		step     time.Duration
+		want     int // number of backups after three writes
+	}{
+		{name: "hourly rotates every hour", interval: RotateHourly, step: time.Hour, want: 2},
+		{name: "hourly keeps writes within the hour", interval: RotateHourly, step: time.Minute, want: 0},
+		{name: "daily rotates at midnight", interval: RotateDaily, step: 12 * time.Hour, want: 1},
+		{name: "never does not rotate", interval: RotateNever, step: 48 * time.Hour, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start := time.Date(2025, 1, 9, 10, 30, 0, 0, time.Local)
+			r, advance, dir := newTestRotatingFile(t, RotateOptions{Interval: tt.interval}, start)
+
+			for range 3 {
+				if _, err := r.Write([]byte("line\n")); err != nil {
+					t.Fatalf("Write() error = %v", err)
+				}
+				advance(tt.step)
+			}
+			if err := r.Close(); err != nil {
+				t.Fatalf("Close() error = %v", err)
+			}
+
+			if got := len(listDir(t, dir)) - 1; got != tt.want {
+				t.Errorf("backups = %d, want %d (%v)", got, tt.want, listDir(t, dir))
+			}
+		})
+	}
+}
+
+func TestRotatingFile_Retention(t *testing.T) {
+	tests := []struct {
+		name string
+		opts RotateOptions
+		want []string
+	}{
+		{
+			name: "max backups keeps the newest",
+			opts: RotateOptions{MaxBackups: 2},
+			want: []string{
+				"app-2025-01-09T12-03-00.000.log",
+				"app-2025-01-09T12-04-00.000.log",
+				"app.log",
+			},
+		},
+		{
+			name: "max age removes old backups",
+			opts: RotateOptions{MaxAge: 90 * time.Second},
+			want: []string{
+				"app-2025-01-09T12-03-00.000.log",
+				"app-2025-01-09T12-04-00.000.log",
+				"app.log",
+			},
+		},
+		{
+			name: "compress gzips backups",
+			opts: RotateOptions{MaxBackups: 1, Compress: true},
+			want: []string{
+				"app-2025-01-09T12-04-00.000.log.gz",
+				"app.log",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start := time.Date(2025, 1, 9, 12, 0, 0, 0, time.Local)
+			r, advance, dir := newTestRotatingFile(t, tt.opts, start)
+
+			for range 4 {
+				advance(time.Minute)
+				if _, err := r.Write([]byte("line\n")); err != nil {
+					t.Fatalf("Write() error = %v", err)
+				}
+				if err := r.Rotate(); err != nil {
+					t.Fatalf("Rotate() error = %v", err)
+				}
+			}
+			if err := r.Close(); err != nil {
+				t.Fatalf("Close() error = %v", err)
+			}
+
+			if got := listDir(t, dir); strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Fatalf("files = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRotatingFile_CompressedContent(t *testing.T) {
+	start := time.Date(2025, 1, 9, 12, 0, 0, 0, time.Local)
+	r, _, dir := newTestRotatingFile(t, RotateOptions{Compress: true}, start)
+
+	if _, err := r.Write([]byte("hello\n")); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if err := r.Rotate(); err != nil {
+		t.Fatalf("Rotate() error = %v", err)
+	}
+	if err := r.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	f, err := os.Open(filepath.Join(dir, "app-2025-01-09T12-00-00.000.log.gz"))
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatalf("gzip.NewReader() error = %v", err)
+	}
+	data, err := io.ReadAll(gz)
+	if err != nil {
+		t.Fatalf("ReadAll() error = %v", err)
+	}
+	if string(data) != "hello\n" {
+		t.Errorf("content = %q, want %q", data, "hello\n")
+	}
+}
+
+func TestRotatingFile_RotateReopensMovedFile(t *testing.T) {
+	start := time.Date(2025, 1, 9, 12, 0, 0, 0, time.Local)
+	r, _, dir := newTestRotatingFile(t, RotateOptions{}, start)
+	defer r.Close()
+
+	if _, err := r.Write([]byte("before\n")); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	// Simulate an external logrotate moving the file away before SIGHUP
+	if err := os.Rename(filepath.Join(dir, "app.log"), filepath.Join(dir, "moved.log")); err != nil {
+		t.Fatalf("Rename() error = %v", err)
+	}
+	if err := r.Rotate(); err != nil {
+		t.Fatalf("Rotate() error = %v", err)
+	}
+	if _, err := r.Write([]byte("after\n")); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "app.log"))
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if string(data) != "after\n" {
+		t.Errorf("active file = %q, want %q", data, "after\n")
+	}
+}
+
+func TestRotatingFile_ReopenAfterExternalRotation(t *testing.T) {
+	start := time.Date(2025, 1, 9, 12, 0, 0, 0, time.Local)
+	r, _, dir := newTestRotatingFile(t, RotateOptions{}, start)
+	defer r.Close()
+
+	if _, err := r.Write([]byte("before\n")); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	// Simulate logrotate in its default create mode: move the file away and
+	// create an empty one in its place
+	name := filepath.Join(dir, "app.log")
+	if err := os.Rename(name, name+".1"); err != nil {
+		t.Fatalf("Rename() error = %v", err)
+	}
+	if err := os.WriteFile(name, nil, 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	if err := r.Reopen(); err != nil {
+		t.Fatalf("Reopen() error = %v", err)
+	}
+	if _, err := r.Write([]byte("after\n")); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	data, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if string(data) != "after\n" {
+		t.Errorf("active file = %q, want %q", data, "after\n")
+	}
+	if got := strings.Join(listDir(t, dir), ","); got != "app.log,app.log.1" {
+		t.Errorf("files = %q, want no backup created by Reopen", got)
+	}
+}
+
+func TestRotatingFile_FailedRotateKeepsWriting(t *testing.T) {
+	start := time.Date(2025, 1, 9, 12, 0, 0, 0, time.Local)
+	r, _, dir := newTestRotatingFile(t, RotateOptions{}, start)
+	defer r.Close()
+
+	// Without its directory, the new file can't be opened
+	if err := os.RemoveAll(dir); err != nil {
+		t.Fatalf("RemoveAll() error = %v", err)
+	}
+	if err := r.Rotate(); err == nil {
+		t.Fatal("Rotate() error = nil, want an error")
+	}
+	if _, err := r.Write([]byte("still open\n")); err != nil {
+		t.Fatalf("Write() after a failed Rotate() error = %v", err)
+	}
+
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		t.Fatalf("MkdirAll() error = %v", err)
+	}
+	if err := r.Rotate(); err != nil {
+		t.Fatalf("Rotate() after recovery error = %v", err)
+	}
+	if _, err := r.Write([]byte("after\n")); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "app.log"))
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if string(data) != "after\n" {
+		t.Errorf("active file = %q, want %q", data, "after\n")
+	}
+}
+
+func TestRotatingFile_FailedSizeRotationKeepsWriting(t *testing.T) {
+	start := time.Date(2025, 1, 9, 12, 0, 0, 0, time.Local)
+	r, advance, dir := newTestRotatingFile(t, RotateOptions{MaxSize: 10}, start)
+	defer r.Close()
+
+	if _, err := r.Write([]byte("12345678\n")); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		t.Fatalf("RemoveAll() error = %v", err)
+	}
+
+	// The rotation fails, but the entry is still written to the open file
+	line := []byte("next\n")
+	if n, err := r.Write(line); n != len(line) || err == nil {
+		t.Fatalf("Write() = %d, %v, want %d and the rotation error", n, err, len(line))
+	}
+	// Rotating is not retried on every write
+	if n, err := r.Write(line); n != len(line) || err != nil {
+		t.Fatalf("Write() during the retry delay = %d, %v, want %d, nil", n, err, len(line))
+	}
+
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		t.Fatalf("MkdirAll() error = %v", err)
+	}
+	advance(rotateRetryDelay)
+	if _, err := r.Write([]byte("after\n")); err != nil {
+		t.Fatalf("Write() after the retry delay error = %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "app.log"))
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if string(data) != "after\n" {
+		t.Errorf("active file = %q, want %q", data, "after\n")
+	}
+}
+
+func TestRotatingFile_ConcurrentWrites(t *testing.T) {
+	dir := t.TempDir()
+	r, err := NewRotatingFile(filepath.Join(dir, "app.log"), RotateOptions{MaxSize: 256})
+	if err != nil {
+		t.Fatalf("NewRotatingFile() error = %v", err)
+	}
+
+	logger := NewLogger("Test", DEBUG, r)
+	logger.SetColors(false)
+
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 50 {
+				logger.Infow("concurrent write", "worker", i)
+			}
+		}()
+	}
+	wg.Wait()
+	if err := r.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	lines := 0
+	for _, name := range listDir(t, dir) {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatalf("ReadFile() error = %v", err)
+		}
+		lines += strings.Count(string(data), "\n")
+	}
+	if lines != 400 {
+		t.Errorf("lines = %d, want 400", lines)
+	}
+
+	if _, err := r.Write([]byte("late\n")); err == nil {
+		t.Error("expected Write after Close to fail")
+	}
+}
@@ -0,0 +1,321 @@
+package logging
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/kashifkhan0771/utils/clock"
+)
+
+// DefaultSamplingInterval is the window length used when SamplingOptions.Interval is not set.
+const DefaultSamplingInterval = time.Second
+
+// SamplingOptions configures log sampling. Entries are counted per level and
+// message in fixed windows, like ratelimiter.FixedWindow: the first First
+// entries of each key are logged, then only every Thereafter-th one.
+type SamplingOptions struct {
+	Interval   time.Duration // Length of each sampling window; defaults to DefaultSamplingInterval
+	First      int           // Entries per key logged in each window before sampling starts
+	Thereafter int           // After First, log every Thereafter-th entry; 0 drops the rest of the window
+}
+
+// entryKey identifies entries that are counted together by sampling.
+type entryKey struct {
+	level   LogLevel
+	message string
+}
+
+// burstKey identifies entries that are duplicates of each other: besides the
+// level and message, the prefix and the fields must match as well.
+type burstKey struct {
+	entryKey
+	prefix string
+	fields string // Fields in key=value form
+}
+
+// newBurstKey returns the key of the entry for duplicate suppression.
+func newBurstKey(entry *Entry) burstKey {
+	return burstKey{
+		entryKey: entryKey{level: entry.Level, message: entry.Message},
+		prefix:   entry.Prefix,
+		fields:   fmt.Sprint(entry.Fields),
+	}
+}
+
+// sampler counts entries per key in a fixed window shared by all keys.
+type sampler struct {
+	mu        sync.Mutex
+	opts      SamplingOptions
+	windowEnd time.Time
+	counts    map[entryKey]int
+}
+
+// newSampler creates a sampler, applying defaults to opts.
+func newSampler(opts SamplingOptions) *sampler {
+	if opts.Interval <= 0 {
+		opts.Interval = DefaultSamplingInterval
+	}
+
+	return &sampler{opts: opts, counts: make(map[entryKey]int)}
+}
+
+// allow reports whether the entry with the given key should be logged at now.
+func (s *sampler) allow(now time.Time, key entryKey) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !now.Before(s.windowEnd) {
+		clear(s.counts)
+		s.windowEnd = now.Add(s.opts.Interval)
+	}
+
+	s.counts[key]++
+	n := s.counts[key]
+	if n <= s.opts.First {
+		return true
+	}
+	if s.opts.Thereafter <= 0 {
+		return false
+	}
+
+	return (n-s.opts.First)%s.opts.Thereafter == 0
+}
+
+// burst tracks repeated entries with the same key.
+type burst struct {
+	logger   *Logger     // Logger that wrote the first entry, used for the summary
+	format   entryFormat // Format of the logger when the first entry was logged
+	entry    *Entry      // First entry of the burst
+	lastSeen time.Time
+	repeated int // Number of suppressed duplicates
+}
+
+// deduper suppresses repeated entries and emits a summary once a burst ends,
+// i.e. when no duplicate has been seen for the configured window.
+type deduper struct {
+	mu     sync.Mutex
+	window time.Duration
+	clock  clock.Clock
+	bursts map[burstKey]*burst
+	timer  clock.Timer   // Wakes up the sweeper to emit summaries when no further entries arrive
+	done   chan struct{} // Closed to stop the sweeper waiting on timer
+}
+
+// newDeduper creates a deduper that ends bursts after window of silence,
+// measured with clk.
+func newDeduper(window time.Duration, clk clock.Clock) *deduper {
+	return &deduper{window: window, clock: clk, bursts: make(map[burstKey]*burst)}
+}
+
+// suppress records the entry and reports whether it is a duplicate that must
+// not be written. It also returns the summaries of bursts that have ended.
+func (d *deduper) suppress(l *Logger, entry *Entry, format entryFormat, now time.Time) (bool, []*burst) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	ended := d.collect(now, false)
+
+	key := newBurstKey(entry)
+	if b, ok := d.bursts[key]; ok {
+		b.repeated++
+		b.lastSeen = now
+
+		return true, ended
+	}
+
+	d.bursts[key] = &burst{logger: l, format: format, entry: entry, lastSeen: now}
+	if d.timer == nil {
+		d.startSweeper()
+	}
+
+	return false, ended
+}
+
+// startSweeper starts a timer on the clock and a goroutine that sweeps when it
+// fires. Caller must hold the mutex.
+func (d *deduper) startSweeper() {
+	d.timer = d.clock.NewTimer(d.window)
+	d.done = make(chan struct{})
+	go d.sweeper(d.timer, d.done)
+}
+
+// stopSweeper stops the timer and the sweeper goroutine, if running.
+// Caller must hold the mutex.
+func (d *deduper) stopSweeper() {
+	if d.timer == nil {
+		return
+	}
+	d.timer.Stop()
+	close(d.done)
+	d.timer = nil
+	d.done = nil
+}
+
+// sweeper sweeps each time timer fires, until no bursts are pending or done
+// is closed.
+func (d *deduper) sweeper(timer clock.Timer, done <-chan struct{}) {
+	for {
+		select {
+		case now := <-timer.C():
+			if !d.sweep(timer, now) {
+				return
+			}
+		case <-done:
+			return
+		}
+	}
+}
+
+// collect removes bursts that ended before now (or all bursts if all is set)
+// and returns those that suppressed at least one duplicate, oldest first.
+// Caller must hold the mutex.
+func (d *deduper) collect(now time.Time, all bool) []*burst {
+	var ended []*burst
+	for key, b := range d.bursts {
+		if !all && now.Sub(b.lastSeen) < d.window {
+			continue
+		}
+		delete(d.bursts, key)
+		if b.repeated > 0 {
+			ended = append(ended, b)
+		}
+	}
+	slices.SortFunc(ended, func(a, b *burst) int {
+		return a.entry.Time.Compare(b.entry.Time)
+	})
+
+	return ended
+}
+
+// sweep emits the summaries of bursts that have ended at now, the time timer
+// fired, and rearms timer while bursts are pending. It returns false once the
+// sweeper should exit, either because no bursts are left or because timer has
+// been replaced.
+func (d *deduper) sweep(timer clock.Timer, now time.Time) bool {
+	d.mu.Lock()
+	if d.timer != timer {
+		d.mu.Unlock()
+
+		return false
+	}
+	ended := d.collect(now, false)
+	pending := len(d.bursts) > 0
+	if pending {
+		timer.Reset(d.window)
+	} else {
+		d.timer = nil
+		d.done = nil
+	}
+	d.mu.Unlock()
+
+	emitSummaries(ended, now)
+
+	return pending
+}
+
+// setClock replaces the clock, restarting the sweeper on the new clock if
+// bursts are pending.
+func (d *deduper) setClock(clk clock.Clock) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.clock = clk
+	if d.timer != nil {
+		d.stopSweeper()
+		d.startSweeper()
+	}
+}
+
+// stop cancels the sweeper and returns the summaries of all pending bursts.
+func (d *deduper) stop() []*burst {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.stopSweeper()
+
+	return d.collect(time.Time{}, true)
+}
+
+// emitSummaries writes a "message repeated N times" entry for each burst.
+func emitSummaries(ended []*burst, now time.Time) {
+	for _, b := range ended {
+		summary := *b.entry
+		summary.Time = now
+		summary.Message = fmt.Sprintf("%s (message repeated %d times)", b.entry.Message, b.repeated)
+		b.logger.dispatch(&summary, b.format)
+	}
+}
+
+// SetSampling enables sampling for the logger and the loggers sharing its
+// sinks. Within each Interval, the first First entries with a given level and
+// message are logged, then every Thereafter-th one. The zero value disables
+// sampling.
+//
+// Parameters:
+//   - opts: The sampling window and rates.
+func (l *Logger) SetSampling(opts SamplingOptions) {
+	var s *sampler
+	if opts != (SamplingOptions{}) {
+		s = newSampler(opts)
+	}
+
+	l.out.mu.Lock()
+	l.out.sampler = s
+	l.out.mu.Unlock()
+}
+
+// SetDeduplication collapses repeated entries with the same level, prefix,
+// message and fields for the logger and the loggers sharing its sinks. The first entry is
+// written; duplicates are counted, and once none has been seen for window a
+// single "<message> (message repeated N times)" entry is written. Pending
+// summaries are written by Close. A window <= 0 disables deduplication.
+//
+// Parameters:
+//   - window: How long a burst may go without a duplicate before it ends.
+func (l *Logger) SetDeduplication(window time.Duration) {
+	l.out.mu.Lock()
+	old := l.out.deduper
+	l.out.deduper = nil
+	if window > 0 {
+		l.out.deduper = newDeduper(window, l.out.clock)
+	}
+	l.out.mu.Unlock()
+
+	if old != nil {
+		emitSummaries(old.stop(), l.out.now())
+	}
+}
+
+// SetClock replaces the clock used for timestamps, sampling windows and
+// duplicate suppression, e.g. with a clock.Fake in tests so that bursts end
+// when the fake clock is advanced. Passing nil restores the real clock. The
+// clock is shared with child loggers.
+//
+// Parameters:
+//   - c: The clock to use.
+func (l *Logger) SetClock(c clock.Clock) {
+	c = clock.OrDefault(c)
+
+	l.out.mu.Lock()
+	l.out.clock = c
+	if l.out.deduper != nil {
+		l.out.deduper.setClock(c)
+	}
+	l.out.mu.Unlock()
+}
+
+// dedupe applies duplicate suppression to the entry and returns whether it
+// should be written, after writing the summaries of any ended bursts.
+func (l *Logger) dedupe(entry *Entry, format entryFormat, now time.Time) bool {
+	d := l.out.dedup()
+	if d == nil {
+		return true
+	}
+
+	suppressed, ended := d.suppress(l, entry, format, now)
+	emitSummaries(ended, now)
+
+	return !suppressed
+}
@@ -0,0 +1,173 @@
+package logging_test
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kashifkhan0771/utils/clock"
+	"github.com/kashifkhan0771/utils/logging"
+)
+
+// newTestClock returns a fake clock at a fixed time.
+func newTestClock() *clock.Fake {
+	return clock.NewFake(time.Date(2025, 1, 9, 12, 0, 0, 0, time.UTC))
+}
+
+func TestLoggerSampling(t *testing.T) {
+	tests := []struct {
+		name string
+		opts logging.SamplingOptions
+		want int // entries written out of 20 identical ones in one window
+	}{
+		{name: "first only", opts: logging.SamplingOptions{First: 3}, want: 3},
+		{name: "first then one in five", opts: logging.SamplingOptions{First: 3, Thereafter: 5}, want: 6},
+		{name: "disabled", opts: logging.SamplingOptions{}, want: 20},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buffer := &bytes.Buffer{}
+			clk := newTestClock()
+			logger := logging.NewLogger("T", logging.DEBUG, buffer)
+			logger.SetClock(clk)
+			logger.SetSampling(tt.opts)
+
+			for range 20 {
+				logger.Warn("dependency down")
+			}
+
+			if got := strings.Count(buffer.String(), "\n"); got != tt.want {
+				t.Errorf("lines = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoggerSamplingWindowAndKeys(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	clk := newTestClock()
+	logger := logging.NewLogger("T", logging.DEBUG, buffer)
+	logger.SetColors(false)
+	logger.SetClock(clk)
+	logger.SetSampling(logging.SamplingOptions{Interval: time.Second, First: 1})
+
+	logger.Warn("a")
+	logger.Warn("a")  // sampled out
+	logger.Error("a") // different level, different key
+	logger.Warn("b")  // different message, different key
+	clk.Advance(time.Second)
+	logger.Warn("a") // new window
+
+	if got := strings.Join(messages(buffer.String()), ","); got != "a,a,b,a" {
+		t.Errorf("messages = %q, want %q", got, "a,a,b,a")
+	}
+}
+
+func TestLoggerDeduplication(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	clk := newTestClock()
+	logger := logging.NewLogger("T", logging.DEBUG, buffer)
+	logger.SetColors(false)
+	logger.SetClock(clk)
+	logger.SetDeduplication(time.Second)
+
+	for range 524 {
+		logger.Warn("connection refused")
+		clk.Advance(time.Millisecond)
+	}
+	logger.Info("other message") // the burst is still active
+
+	clk.Advance(time.Second)
+	logger.Info("recovered") // the burst has ended
+
+	want := "connection refused,other message,connection refused (message repeated 523 times),recovered"
+	if got := strings.Join(messages(buffer.String()), ","); got != want {
+		t.Errorf("messages = %q, want %q", got, want)
+	}
+}
+
+func TestLoggerDeduplicationFlushedOnClose(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	clk := newTestClock()
+	logger := logging.NewLogger("T", logging.DEBUG, buffer)
+	logger.SetColors(false)
+	logger.SetClock(clk)
+	logger.SetDeduplication(time.Minute)
+
+	child := logger.With("db", "primary")
+	child.Error("query failed")
+	child.Error("query failed")
+	child.Error("query failed")
+	logger.Error("query failed") // different fields, not a duplicate
+	logger.Warn("single")        // never repeated, so no summary
+
+	if err := logger.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	want := "query failed db=primary,query failed,single,query failed (message repeated 2 times) db=primary"
+	if got := strings.Join(messages(buffer.String()), ","); got != want {
+		t.Errorf("messages = %q, want %q", got, want)
+	}
+}
+
+func TestLoggerDeduplicationTimer(t *testing.T) {
+	writer := &lineWriter{lines: make(chan string, 4)}
+	clk := newTestClock()
+	logger := logging.NewLogger("T", logging.DEBUG, writer)
+	logger.SetColors(false)
+	logger.SetClock(clk)
+	logger.SetDeduplication(time.Second)
+	defer logger.SetDeduplication(0)
+
+	logger.Warn("flapping")
+	logger.Warn("flapping")
+	<-writer.lines
+
+	// The burst ends on the fake clock, without further entries
+	clk.WaitForTimers(1)
+	clk.Advance(time.Second)
+
+	select {
+	case line := <-writer.lines:
+		if !strings.Contains(line, "flapping (message repeated 1 times)") {
+			t.Errorf("summary = %q, want the repeated message", line)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected a summary once the burst ended")
+	}
+}
+
+// lineWriter sends every write to a channel.
+type lineWriter struct {
+	lines chan string
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.lines <- string(p)
+
+	return len(p), nil
+}
+
+// lockedBuffer is a bytes.Buffer that is safe for concurrent use.
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.String()
+}
@@ -0,0 +1,196 @@
+package logging
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/kashifkhan0771/utils/clock"
+)
+
+// Sink is an output destination with its own level threshold, encoder and
+// color setting. A Logger fans each entry out to all of its sinks.
+type Sink struct {
+	Writer        io.Writer // Destination of log entries
+	MinLevel      LogLevel  // Minimum level written to this sink, on top of the logger's minimum level
+	Encoder       Encoder   // Encoder for this sink; the logger's encoder is used if nil
+	DisableColors bool      // Disable colors for this sink even if the logger has them enabled
+}
+
+// WriteError reports a failure to encode or write an entry to a sink.
+type WriteError struct {
+	Sink Sink  // The sink that failed
+	Err  error // The underlying error
+}
+
+// Error implements the error interface.
+func (e *WriteError) Error() string {
+	return fmt.Sprintf("failed to write log: %v", e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *WriteError) Unwrap() error {
+	return e.Err
+}
+
+// sink is a Sink with a lock that serializes writes to its writer.
+type sink struct {
+	Sink
+	mu sync.Mutex
+}
+
+// outputs holds the sinks, error handler and asynchronous queue of a logger.
+// It is shared between a logger and its children.
+type outputs struct {
+	mu           sync.RWMutex
+	sinks        []*sink
+	errorHandler func(err error)
+	async        *asyncQueue   // Nil unless asynchronous logging is enabled
+	dropped      atomic.Uint64 // Entries discarded by the asynchronous queue
+	sampler      *sampler      // Nil unless sampling is enabled
+	deduper      *deduper      // Nil unless duplicate suppression is enabled
+	clock        clock.Clock   // Time source for timestamps, sampling and deduplication
+}
+
+// newOutputs creates outputs with the given sinks.
+func newOutputs(sinks ...Sink) *outputs {
+	o := &outputs{sinks: make([]*sink, 0, len(sinks)), clock: clock.New()}
+	for _, s := range sinks {
+		o.add(s)
+	}
+
+	return o
+}
+
+// add appends a sink. A nil writer defaults to os.Stdout.
+func (o *outputs) add(s Sink) {
+	if s.Writer == nil {
+		s.Writer = os.Stdout
+	}
+
+	o.mu.Lock()
+	o.sinks = append(o.sinks, &sink{Sink: s})
+	o.mu.Unlock()
+}
+
+// snapshot returns the current sinks and error handler.
+func (o *outputs) snapshot() ([]*sink, func(error)) {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	return o.sinks, o.errorHandler
+}
+
+// queue returns the asynchronous queue, or nil if logging is synchronous.
+func (o *outputs) queue() *asyncQueue {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	return o.async
+}
+
+// now returns the current time according to the configured clock.
+func (o *outputs) now() time.Time {
+	o.mu.RLock()
+	clk := o.clock
+	o.mu.RUnlock()
+
+	return clk.Now()
+}
+
+// sampling returns the sampler, or nil if sampling is disabled.
+func (o *outputs) sampling() *sampler {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	return o.sampler
+}
+
+// dedup returns the deduper, or nil if duplicate suppression is disabled.
+func (o *outputs) dedup() *deduper {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	return o.deduper
+}
+
+// reportError passes err to the error handler, or prints it to os.Stderr if
+// no handler is set.
+func reportError(handler func(error), err error) {
+	if handler != nil {
+		handler(err)
+
+		return
+	}
+
+	_, _ = fmt.Fprintf(os.Stderr, "%v\n", err)
+}
+
+// NewMultiSinkLogger creates a Logger that writes every entry to each of the
+// given sinks whose MinLevel it meets. Sinks are written independently: a
+// failing sink is reported through the error handler and does not prevent the
+// others from being written.
+//
+// Parameters:
+//   - prefix: A string prefix that will appear in all log messages.
+//   - minLevel: The minimum log level for a message to be logged to any sink.
+//   - sinks: The output destinations. A sink with a nil Writer writes to os.Stdout.
+//
+// Returns:
+//
+//	A pointer to a configured Logger instance.
+func NewMultiSinkLogger(prefix string, minLevel LogLevel, sinks ...Sink) *Logger {
+	return newLogger(prefix, minLevel, newOutputs(sinks...))
+}
+
+// AddSink adds an output destination to the logger. Sinks are shared with
+// child loggers created by With.
+//
+// Parameters:
+//   - s: The sink to add. A nil Writer writes to os.Stdout.
+func (l *Logger) AddSink(s Sink) {
+	l.out.add(s)
+}
+
+// SetErrorHandler sets a hook that receives encoding and write errors, each
+// as a *WriteError (or the handler's error for loggers built with
+// NewSlogLogger). Without a handler, errors are printed to os.Stderr. The
+// handler is shared with child loggers and must be safe for concurrent use.
+//
+// Parameters:
+//   - handler: The function called for each error, or nil to restore the default.
+func (l *Logger) SetErrorHandler(handler func(err error)) {
+	l.out.mu.Lock()
+	l.out.errorHandler = handler
+	l.out.mu.Unlock()
+}
+
+// writeSinks encodes the entry for every sink whose level it meets and
+// writes it. Sinks without their own encoder use the encoder of format.
+// Errors are reported per sink and do not stop the other sinks.
+func (l *Logger) writeSinks(entry *Entry, format entryFormat) {
+	sinks, errorHandler := l.out.snapshot()
+	for _, s := range sinks {
+		if entry.Level < s.MinLevel {
+			continue
+		}
+
+		encoder := s.Encoder
+		if encoder == nil {
+			encoder = format.encoder
+		}
+
+		data, err := encode(encoder, entry, format.colored && !s.DisableColors)
+		if err == nil {
+			s.mu.Lock()
+			_, err = s.Writer.Write(data)
+			s.mu.Unlock()
+		}
+		if err != nil {
+			reportError(errorHandler, &WriteError{Sink: s.Sink, Err: err})
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package logging_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/kashifkhan0771/utils/logging"
+)
+
+// failingWriter always returns its error.
+type failingWriter struct {
+	err error
+}
+
+func (w failingWriter) Write([]byte) (int, error) {
+	return 0, w.err
+}
+
+func TestMultiSinkLogger(t *testing.T) {
+	stderr := &bytes.Buffer{}
+	file := &bytes.Buffer{}
+	logger := logging.NewMultiSinkLogger("MyApp", logging.DEBUG,
+		logging.Sink{Writer: stderr, MinLevel: logging.ERROR},
+		logging.Sink{Writer: file, Encoder: logging.NewJSONEncoder(logging.EncoderConfig{})},
+	)
+
+	logger.Debug("cache warmed")
+	logger.Errorw("db unreachable", "host", "db-1")
+
+	if got := strings.Count(stderr.String(), "\n"); got != 1 {
+		t.Fatalf("Expected 1 line on the ERROR sink, got %d: %q", got, stderr.String())
+	}
+	if !strings.HasPrefix(stderr.String(), logging.ColorRed) || !strings.Contains(stderr.String(), "db unreachable host=db-1") {
+		t.Errorf("Expected a colored text ERROR line, got %q", stderr.String())
+	}
+
+	lines := strings.Split(strings.TrimSpace(file.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 lines on the JSON sink, got %d: %q", len(lines), file.String())
+	}
+	for _, line := range lines {
+		var decoded map[string]any
+		if err := json.Unmarshal([]byte(line), &decoded); err != nil {
+			t.Errorf("Expected JSON line, got %q: %v", line, err)
+		}
+	}
+}
+
+func TestSinkColors(t *testing.T) {
+	colored := &bytes.Buffer{}
+	plain := &bytes.Buffer{}
+	logger := logging.NewMultiSinkLogger("Test", logging.INFO,
+		logging.Sink{Writer: colored},
+		logging.Sink{Writer: plain, DisableColors: true},
+	)
+
+	logger.Warn("careful")
+
+	if !strings.HasPrefix(colored.String(), logging.ColorYellow) {
+		t.Errorf("Expected colored output, got %q", colored.String())
+	}
+	if strings.Contains(plain.String(), "\033[") {
+		t.Errorf("Expected plain output, got %q", plain.String())
+	}
+
+	logger.SetColors(false)
+	colored.Reset()
+	logger.Warn("careful")
+	if strings.Contains(colored.String(), "\033[") {
+		t.Errorf("Expected logger setting to disable colors on all sinks, got %q", colored.String())
+	}
+}
+
+func TestSinkFailureDoesNotBlockOthers(t *testing.T) {
+	writeErr := errors.New("disk full")
+	healthy := &bytes.Buffer{}
+	logger := logging.NewLogger("Test", logging.INFO, failingWriter{err: writeErr})
+	logger.AddSink(logging.Sink{Writer: healthy})
+
+	var mu sync.Mutex
+	var reported []error
+	logger.SetErrorHandler(func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		reported = append(reported, err)
+	})
+
+	logger.With("k", "v").Info("still written")
+
+	if !strings.Contains(healthy.String(), "still written k=v") {
+		t.Errorf("Expected healthy sink to be written, got %q", healthy.String())
+	}
+	if len(reported) != 1 {
+		t.Fatalf("Expected 1 reported error, got %d", len(reported))
+	}
+
+	var we *logging.WriteError
+	if !errors.As(reported[0], &we) || !errors.Is(reported[0], writeErr) {
+		t.Fatalf("Expected a *WriteError wrapping %v, got %v", writeErr, reported[0])
+	}
+	if _, ok := we.Sink.Writer.(failingWriter); !ok {
+		t.Errorf("Expected the failing sink to be reported, got %T", we.Sink.Writer)
+	}
+}
@@ -0,0 +1,194 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+)
+
+// SlogHandler is an slog.Handler that writes records through a Logger, so
+// that output produced with log/slog keeps the Logger's prefix, minimum level,
+// redaction rules, encoder and colors.
+//
+// slog levels are mapped to the nearest LogLevel at or below them: anything
+// below slog.LevelInfo is DEBUG, below slog.LevelWarn is INFO, below
+// slog.LevelError is WARN, and everything else is ERROR. Attributes become
+// structured fields, and attributes inside groups use dotted keys
+// (e.g. "request.method").
+type SlogHandler struct {
+	logger *Logger
+	groups []string // Open groups, joined with "." as a key prefix
+}
+
+// NewSlogHandler creates an slog.Handler backed by the given Logger.
+//
+// Parameters:
+//   - logger: The Logger that records are written to.
+//
+// Returns:
+//
+//	A pointer to a SlogHandler, usable with slog.New.
+func NewSlogHandler(logger *Logger) *SlogHandler {
+	return &SlogHandler{logger: logger}
+}
+
+// Enabled reports whether the Logger would write a record at the given level.
+func (h *SlogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return fromSlogLevel(level) >= h.logger.Level()
+}
+
+// Handle writes the record through the Logger.
+func (h *SlogHandler) Handle(_ context.Context, r slog.Record) error {
+	fields := make([]Field, 0, r.NumAttrs())
+	prefix := h.keyPrefix()
+	r.Attrs(func(a slog.Attr) bool {
+		fields = appendAttr(fields, prefix, a)
+
+		return true
+	})
+
+	h.logger.logAt(r.Time, r.PC, fromSlogLevel(r.Level), r.Message, fields)
+
+	return nil
+}
+
+// WithAttrs returns a handler whose records include the given attributes.
+func (h *SlogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	fields := make([]Field, 0, len(attrs))
+	prefix := h.keyPrefix()
+	for _, a := range attrs {
+		fields = appendAttr(fields, prefix, a)
+	}
+	if len(fields) == 0 {
+		return h
+	}
+
+	args := make([]any, len(fields))
+	for i, f := range fields {
+		args[i] = f
+	}
+
+	return &SlogHandler{logger: h.logger.With(args...), groups: h.groups}
+}
+
+// WithGroup returns a handler that nests subsequent attributes under name.
+func (h *SlogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+
+	groups := make([]string, len(h.groups), len(h.groups)+1)
+	copy(groups, h.groups)
+
+	return &SlogHandler{logger: h.logger, groups: append(groups, name)}
+}
+
+// keyPrefix returns the dotted prefix for keys in the currently open groups.
+func (h *SlogHandler) keyPrefix() string {
+	if len(h.groups) == 0 {
+		return ""
+	}
+
+	return strings.Join(h.groups, ".") + "."
+}
+
+// appendAttr flattens a into fields, resolving LogValuers and expanding
+// groups into dotted keys. Empty attributes and empty groups are dropped.
+func appendAttr(fields []Field, prefix string, a slog.Attr) []Field {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return fields
+	}
+
+	if a.Value.Kind() == slog.KindGroup {
+		groupPrefix := prefix
+		if a.Key != "" {
+			groupPrefix += a.Key + "."
+		}
+		for _, ga := range a.Value.Group() {
+			fields = appendAttr(fields, groupPrefix, ga)
+		}
+
+		return fields
+	}
+
+	return append(fields, Field{Key: prefix + a.Key, Value: a.Value.Any()})
+}
+
+// NewSlogLogger creates a Logger that writes its entries to an existing
+// slog.Handler instead of an io.Writer. Level filtering, structured fields and
+// redaction are applied by the Logger before the record is handed over;
+// formatting is left to the handler, so encoders and colors do not apply.
+// A non-empty prefix is passed as the "prefix" attribute. The logger starts
+// without sinks; sinks added with AddSink receive entries as well.
+//
+// Parameters:
+//   - prefix: A string prefix that will appear in all log messages.
+//   - minLevel: The minimum log level for a message to be logged.
+//   - handler: The slog.Handler that receives the records.
+//
+// Returns:
+//
+//	A pointer to a configured Logger instance.
+func NewSlogLogger(prefix string, minLevel LogLevel, handler slog.Handler) *Logger {
+	l := newLogger(prefix, minLevel, newOutputs())
+	l.handler = handler
+
+	return l
+}
+
+// handleSlog converts the entry to an slog.Record and passes it to the
+// logger's slog.Handler.
+func (l *Logger) handleSlog(entry *Entry) error {
+	ctx := context.Background()
+	level := toSlogLevel(entry.Level)
+	if !l.handler.Enabled(ctx, level) {
+		return nil
+	}
+
+	var pc uintptr
+	if entry.Caller != nil {
+		pc = entry.Caller.pc
+	}
+
+	r := slog.NewRecord(entry.Time, level, entry.Message, pc)
+	if entry.Prefix != "" {
+		r.AddAttrs(slog.String(KeyPrefix, entry.Prefix))
+	}
+	for _, f := range entry.Fields {
+		r.AddAttrs(slog.Any(f.Key, f.Value))
+	}
+	if entry.Stack != "" {
+		r.AddAttrs(slog.String(KeyStack, entry.Stack))
+	}
+
+	return l.handler.Handle(ctx, r)
+}
+
+// toSlogLevel maps a LogLevel to the matching slog.Level.
+func toSlogLevel(level LogLevel) slog.Level {
+	switch {
+	case level <= DEBUG:
+		return slog.LevelDebug
+	case level == INFO:
+		return slog.LevelInfo
+	case level == WARN:
+		return slog.LevelWarn
+	default:
+		return slog.LevelError
+	}
+}
+
+// fromSlogLevel maps an slog.Level to the nearest LogLevel at or below it.
+func fromSlogLevel(level slog.Level) LogLevel {
+	switch {
+	case level < slog.LevelInfo:
+		return DEBUG
+	case level < slog.LevelWarn:
+		return INFO
+	case level < slog.LevelError:
+		return WARN
+	default:
+		return ERROR
+	}
+}
@@ -0,0 +1,119 @@
+package logging_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/kashifkhan0771/utils/logging"
+)
+
+func TestSlogHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		minLevel       logging.LogLevel
+		log            func(l *slog.Logger)
+		wantContains   []string
+		wantNotContain []string
+	}{
+		{
+			name:     "success - levels are mapped",
+			minLevel: logging.DEBUG,
+			log: func(l *slog.Logger) {
+				l.Debug("d")
+				l.Info("i")
+				l.Warn("w")
+				l.Error("e")
+				l.Log(t.Context(), slog.LevelError+4, "fatal")
+			},
+			wantContains: []string{"[DEBUG] Test: d", "[INFO] Test: i", "[WARN] Test: w", "[ERROR] Test: e", "[ERROR] Test: fatal"},
+		},
+		{
+			name:     "success - minimum level is respected",
+			minLevel: logging.WARN,
+			log: func(l *slog.Logger) {
+				l.Info("hidden")
+				l.Warn("shown")
+			},
+			wantContains:   []string{"shown"},
+			wantNotContain: []string{"hidden"},
+		},
+		{
+			name:     "success - attributes and groups become dotted fields",
+			minLevel: logging.INFO,
+			log: func(l *slog.Logger) {
+				l.With("service", "api").WithGroup("req").Info("handled",
+					"method", "GET",
+					slog.Group("user", "id", 7),
+				)
+			},
+			wantContains: []string{"handled service=api req.method=GET req.user.id=7"},
+		},
+		{
+			name:     "success - redaction rules apply to attributes",
+			minLevel: logging.INFO,
+			log: func(l *slog.Logger) {
+				l.Info("login", "password", "hunter2")
+			},
+			wantContains:   []string{"password=***"},
+			wantNotContain: []string{"hunter2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buffer := &bytes.Buffer{}
+			logger := logging.NewLogger("Test", tt.minLevel, buffer)
+			logger.SetRedactionRules(map[string]string{"password=": "***"})
+
+			tt.log(slog.New(logging.NewSlogHandler(logger)))
+
+			output := buffer.String()
+			for _, want := range tt.wantContains {
+				if !strings.Contains(output, want) {
+					t.Errorf("Expected output to contain '%v', got: %v", want, output)
+				}
+			}
+			for _, notWant := range tt.wantNotContain {
+				if strings.Contains(output, notWant) {
+					t.Errorf("Expected output NOT to contain '%v', got: %v", notWant, output)
+				}
+			}
+		})
+	}
+}
+
+func TestNewSlogLogger(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	handler := slog.NewJSONHandler(buffer, &slog.HandlerOptions{Level: slog.LevelDebug})
+	logger := logging.NewSlogLogger("MyApp", logging.INFO, handler)
+	logger.SetRedactionRules(map[string]string{"token=": "[REDACTED]"})
+
+	logger.Debug("filtered out")
+	logger.With("user_id", 42).Warnw("token=abc123 rejected", "retry", true)
+
+	lines := strings.Split(strings.TrimSpace(buffer.String()), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("Expected exactly one record, got %d: %v", len(lines), buffer.String())
+	}
+
+	var record map[string]any
+	if err := json.Unmarshal([]byte(lines[0]), &record); err != nil {
+		t.Fatalf("Expected JSON record, got %q: %v", lines[0], err)
+	}
+
+	want := map[string]any{
+		"level":   "WARN",
+		"msg":     "token=[REDACTED] rejected",
+		"prefix":  "MyApp",
+		"user_id": float64(42),
+		"retry":   true,
+	}
+	for key, value := range want {
+		if record[key] != value {
+			t.Errorf("Expected %s = %v, got %v", key, value, record[key])
+		}
+	}
+}
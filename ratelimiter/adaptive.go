@@ -0,0 +1,142 @@
+package ratelimiter
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/kashifkhan0771/utils/clock"
+)
+
+const (
+	// DefaultAdaptiveInitialLimit is the starting concurrency limit of an AdaptiveLimiter.
+	DefaultAdaptiveInitialLimit = 20
+	// DefaultAdaptiveMaxLimit is the highest concurrency limit of an AdaptiveLimiter.
+	DefaultAdaptiveMaxLimit = 200
+	// DefaultAdaptiveBackoffRatio is the factor the limit is multiplied by after a drop.
+	DefaultAdaptiveBackoffRatio = 0.9
+)
+
+// AdaptiveOptions configures an AdaptiveLimiter.
+type AdaptiveOptions struct {
+	InitialLimit int     // Starting limit. Defaults to DefaultAdaptiveInitialLimit
+	MinLimit     int     // Lowest limit. Defaults to 1
+	MaxLimit     int     // Highest limit. Defaults to DefaultAdaptiveMaxLimit
+	BackoffRatio float64 // Factor in (0, 1) the limit is multiplied by after a drop. Defaults to DefaultAdaptiveBackoffRatio
+
+	// LatencyThreshold counts operations slower than this as drops, even if
+	// they succeeded. 0 means only errors count.
+	LatencyThreshold time.Duration
+
+	// IsDrop reports whether an error means the downstream is overloaded.
+	// By default every error except context.Canceled does.
+	IsDrop func(err error) bool
+
+	// OnLimitChange is called after every change of the limit, e.g. to export metrics.
+	OnLimitChange func(from, to int)
+
+	// Clock measures latency. Defaults to the real clock.
+	Clock clock.Clock
+}
+
+// AdaptiveLimiter is a concurrency limiter that finds its own limit, in the
+// style of Netflix's concurrency-limits AIMD algorithm. Each successful
+// operation that made use of the limit raises it by one (additive increase);
+// each drop, an overload error or a latency above the threshold, multiplies
+// it by the backoff ratio (multiplicative decrease).
+type AdaptiveLimiter struct {
+	mu            sync.Mutex
+	limiter       *ConcurrencyLimiter
+	limit         int
+	minLimit      int
+	maxLimit      int
+	backoffRatio  float64
+	threshold     time.Duration
+	isDrop        func(err error) bool
+	onLimitChange func(from, to int)
+	clock         clock.Clock
+}
+
+// NewAdaptiveLimiter creates an AdaptiveLimiter with the given options.
+// Invalid options are replaced by their defaults, and InitialLimit is clamped between MinLimit and MaxLimit.
+func NewAdaptiveLimiter(opts AdaptiveOptions) *AdaptiveLimiter {
+	if opts.MinLimit < 1 {
+		opts.MinLimit = 1
+	}
+	if opts.MaxLimit < 1 {
+		opts.MaxLimit = DefaultAdaptiveMaxLimit
+	}
+	opts.MaxLimit = max(opts.MaxLimit, opts.MinLimit)
+	if opts.InitialLimit < 1 {
+		opts.InitialLimit = DefaultAdaptiveInitialLimit
+	}
+	opts.InitialLimit = min(max(opts.InitialLimit, opts.MinLimit), opts.MaxLimit)
+	if opts.BackoffRatio <= 0 || opts.BackoffRatio >= 1 {
+		opts.BackoffRatio = DefaultAdaptiveBackoffRatio
+	}
+	if opts.IsDrop == nil {
+		opts.IsDrop = func(err error) bool { return !errors.Is(err, context.Canceled) }
+	}
+
+	return &AdaptiveLimiter{
+		limiter:       NewConcurrencyLimiter(opts.InitialLimit),
+		limit:         opts.InitialLimit,
+		minLimit:      opts.MinLimit,
+		maxLimit:      opts.MaxLimit,
+		backoffRatio:  opts.BackoffRatio,
+		threshold:     max(opts.LatencyThreshold, 0),
+		isDrop:        opts.IsDrop,
+		onLimitChange: opts.OnLimitChange,
+		clock:         clock.OrDefault(opts.Clock),
+	}
+}
+
+// Acquire blocks until the operation may start, or until the context is cancelled.
+// On success, the returned done must be called exactly once when the operation
+// finishes, with its error; it releases the slot and adjusts the limit.
+func (a *AdaptiveLimiter) Acquire(ctx context.Context) (done func(err error), err error) {
+	if err := a.limiter.Acquire(ctx, 1); err != nil {
+		return nil, err
+	}
+
+	start := a.clock.Now()
+	inFlight := a.limiter.InUse()
+
+	var once sync.Once
+
+	return func(err error) {
+		once.Do(func() {
+			latency := a.clock.Now().Sub(start)
+			a.limiter.Release(1)
+			a.record(inFlight, latency, err)
+		})
+	}, nil
+}
+
+// Execute calls fn once the operation may start and reports its outcome.
+// Returns the error of fn, or the error of Acquire without calling fn.
+func (a *AdaptiveLimiter) Execute(ctx context.Context, fn func(ctx context.Context) error) error {
+	done, err := a.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = fn(ctx)
+	done(err)
+
+	return err
+}
+
+// Limit returns the current concurrency limit.
+func (a *AdaptiveLimiter) Limit() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return a.limit
+}
+
+// InUse returns the number of operations in flight.
+func (a *AdaptiveLimiter) InUse() int {
+	return a.limiter.InUse()
+}
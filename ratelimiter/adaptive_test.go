@@ -0,0 +1,161 @@
+package ratelimiter
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestNewAdaptiveLimiter_Defaults(t *testing.T) {
+	a := NewAdaptiveLimiter(AdaptiveOptions{})
+	if a.Limit() != DefaultAdaptiveInitialLimit {
+		t.Errorf("expected limit %d, got %d", DefaultAdaptiveInitialLimit, a.Limit())
+	}
+
+	a = NewAdaptiveLimiter(AdaptiveOptions{InitialLimit: 50, MinLimit: 5, MaxLimit: 10})
+	if a.Limit() != 10 {
+		t.Errorf("expected the initial limit clamped to 10, got %d", a.Limit())
+	}
+}
+
+func TestAdaptiveLimiter_IncreasesWhenBusy(t *testing.T) {
+	a := NewAdaptiveLimiter(AdaptiveOptions{InitialLimit: 2, MaxLimit: 3})
+	ctx := context.Background()
+
+	done1, _ := a.Acquire(ctx)
+	done2, _ := a.Acquire(ctx)
+	done1(nil)
+	if a.Limit() != 3 {
+		t.Fatalf("expected the limit to grow to 3, got %d", a.Limit())
+	}
+	done2(nil)
+	if a.Limit() != 3 {
+		t.Errorf("expected the limit capped at 3, got %d", a.Limit())
+	}
+}
+
+func TestAdaptiveLimiter_NoIncreaseWhenIdle(t *testing.T) {
+	a := NewAdaptiveLimiter(AdaptiveOptions{InitialLimit: 10})
+
+	for range 5 {
+		if err := a.Execute(context.Background(), func(context.Context) error { return nil }); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if a.Limit() != 10 {
+		t.Errorf("expected the limit to stay at 10 with one operation in flight, got %d", a.Limit())
+	}
+}
+
+func TestAdaptiveLimiter_DecreasesOnError(t *testing.T) {
+	var changes [][2]int
+	a := NewAdaptiveLimiter(AdaptiveOptions{
+		InitialLimit:  10,
+		MinLimit:      4,
+		BackoffRatio:  0.5,
+		OnLimitChange: func(from, to int) { changes = append(changes, [2]int{from, to}) },
+	})
+	errBoom := errors.New("boom")
+
+	err := a.Execute(context.Background(), func(context.Context) error { return errBoom })
+	if !errors.Is(err, errBoom) {
+		t.Fatalf("expected the error of fn, got %v", err)
+	}
+	if a.Limit() != 5 {
+		t.Fatalf("expected the limit halved to 5, got %d", a.Limit())
+	}
+
+	_ = a.Execute(context.Background(), func(context.Context) error { return errBoom })
+	if a.Limit() != 4 {
+		t.Errorf("expected the limit to stop at MinLimit 4, got %d", a.Limit())
+	}
+	if len(changes) != 2 || changes[0] != [2]int{10, 5} || changes[1] != [2]int{5, 4} {
+		t.Errorf("unexpected limit changes: %v", changes)
+	}
+	if a.InUse() != 0 {
+		t.Errorf("expected nothing in flight, got %d", a.InUse())
+	}
+}
+
+func TestAdaptiveLimiter_IsDrop(t *testing.T) {
+	errNotFound := errors.New("not found")
+	a := NewAdaptiveLimiter(AdaptiveOptions{
+		InitialLimit: 10,
+		IsDrop:       func(err error) bool { return !errors.Is(err, errNotFound) },
+	})
+
+	_ = a.Execute(context.Background(), func(context.Context) error { return errNotFound })
+	if a.Limit() != 10 {
+		t.Errorf("expected a non-drop error to keep the limit, got %d", a.Limit())
+	}
+
+	a = NewAdaptiveLimiter(AdaptiveOptions{InitialLimit: 10})
+	_ = a.Execute(context.Background(), func(context.Context) error { return context.Canceled })
+	if a.Limit() != 10 {
+		t.Errorf("expected context.Canceled not to count as a drop by default, got %d", a.Limit())
+	}
+}
+
+func TestAdaptiveLimiter_LatencyThreshold(t *testing.T) {
+	clk := newFakeClock()
+	a := NewAdaptiveLimiter(AdaptiveOptions{
+		InitialLimit:     10,
+		LatencyThreshold: 100 * time.Millisecond,
+		Clock:            clk,
+	})
+
+	done, err := a.Acquire(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	clk.Advance(100 * time.Millisecond)
+	done(nil)
+	if a.Limit() != 10 {
+		t.Fatalf("expected a latency at the threshold to keep the limit, got %d", a.Limit())
+	}
+
+	done, _ = a.Acquire(context.Background())
+	clk.Advance(101 * time.Millisecond)
+	done(nil)
+	if a.Limit() != 9 {
+		t.Errorf("expected a slow success to lower the limit to 9, got %d", a.Limit())
+	}
+}
+
+func TestAdaptiveLimiter_DoneOnce(t *testing.T) {
+	a := NewAdaptiveLimiter(AdaptiveOptions{InitialLimit: 10})
+
+	done, _ := a.Acquire(context.Background())
+	done(errors.New("boom"))
+	done(errors.New("boom"))
+	if a.Limit() != 9 {
+		t.Errorf("expected a second done call to be ignored, got limit %d", a.Limit())
+	}
+	if a.InUse() != 0 {
+		t.Errorf("expected nothing in flight, got %d", a.InUse())
+	}
+}
+
+func TestAdaptiveLimiter_BlocksAtLimit(t *testing.T) {
+	a := NewAdaptiveLimiter(AdaptiveOptions{InitialLimit: 1, MaxLimit: 1})
+
+	done, _ := a.Acquire(context.Background())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := a.Acquire(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("expected context.DeadlineExceeded, got %v", err)
+	}
+
+	called := false
+	err := a.Execute(ctx, func(context.Context) error { called = true; return nil })
+	if err == nil || called {
+		t.Fatalf("expected Execute to fail without calling fn, got %v", err)
+	}
+
+	done(nil)
+	if _, err := a.Acquire(context.Background()); err != nil {
+		t.Fatalf("expected a slot after done, got %v", err)
+	}
+}
@@ -0,0 +1,181 @@
+package ratelimiter
+
+import (
+	"context"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/kashifkhan0771/utils/clock"
+)
+
+func newFakeClock() *clock.Fake {
+	return clock.NewFake(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+}
+
+// waitAsync runs wait in a goroutine and returns a channel receiving its result.
+func waitAsync(wait func(ctx context.Context) error) <-chan error {
+	done := make(chan error, 1)
+	go func() { done <- wait(context.Background()) }()
+
+	return done
+}
+
+// expectBlocked fails if done already received a result.
+func expectBlocked(t *testing.T, done <-chan error) {
+	t.Helper()
+
+	select {
+	case err := <-done:
+		t.Fatalf("expected Wait to block, returned %v", err)
+	default:
+	}
+}
+
+func TestSetClock_TokenBucket(t *testing.T) {
+	clk := newFakeClock()
+	tb := NewTokenBucket(2, 1)
+	tb.SetClock(clk)
+	tb.AllowN(2)
+
+	clk.Advance(500 * time.Millisecond)
+	if tb.Allow() {
+		t.Fatal("expected no token after half a second")
+	}
+	clk.Advance(500 * time.Millisecond)
+	if !tb.Allow() {
+		t.Fatal("expected a token after a second")
+	}
+
+	r := tb.ReserveN(2)
+	if r.Delay() != 2*time.Second {
+		t.Errorf("expected a delay of exactly 2s, got %v", r.Delay())
+	}
+	clk.Advance(time.Second)
+	if r.Delay() != time.Second {
+		t.Errorf("expected the delay to follow the clock, got %v", r.Delay())
+	}
+	r.Cancel()
+	if !tb.Allow() {
+		t.Fatal("expected the cancelled reservation to give a token back")
+	}
+
+	done := waitAsync(tb.Wait)
+	clk.WaitForTimers(1)
+	expectBlocked(t, done)
+	clk.Advance(time.Second)
+	if err := <-done; err != nil {
+		t.Fatalf("Wait returned error: %v", err)
+	}
+
+	tb.SetClock(nil)
+	if _, ok := tb.clock.(*clock.Fake); ok {
+		t.Error("expected SetClock(nil) to restore the real clock")
+	}
+}
+
+func TestSetClock_FixedWindow(t *testing.T) {
+	clk := newFakeClock()
+	fw := NewFixedWindow(1, time.Minute)
+	fw.SetClock(clk)
+	fw.Allow()
+
+	done := waitAsync(fw.Wait)
+	clk.WaitForTimers(1)
+	clk.Advance(59 * time.Second)
+	expectBlocked(t, done)
+
+	clk.Advance(time.Second)
+	if err := <-done; err != nil {
+		t.Fatalf("Wait returned error: %v", err)
+	}
+	// The reserved event used up the new window
+	clk.Advance(time.Second)
+	if fw.Allow() {
+		t.Error("expected the window to be full")
+	}
+}
+
+func TestSetClock_SlidingWindows(t *testing.T) {
+	tests := []struct {
+		name    string
+		limiter interface {
+			Allow() bool
+			Wait(ctx context.Context) error
+			SetClock(c clock.Clock)
+		}
+		wait time.Duration // How long after the second event the next one is allowed
+	}{
+		// The first event leaves the window
+		{"log", NewSlidingWindowLog(2, time.Minute), 30 * time.Second},
+		// Half of the previous window's 2 events still count until it is 50% past
+		{"counter", NewSlidingWindowCounter(2, time.Minute), time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := tt.limiter
+			clk := newFakeClock()
+			l.SetClock(clk)
+
+			l.Allow()
+			clk.Advance(30 * time.Second)
+			l.Allow()
+
+			done := waitAsync(l.Wait)
+			clk.WaitForTimers(1)
+			expectBlocked(t, done)
+
+			clk.Advance(tt.wait - time.Second)
+			clk.WaitForTimers(1) // The waiter may have woken up and set a new timer
+			expectBlocked(t, done)
+
+			clk.Advance(time.Second)
+			if err := <-done; err != nil {
+				t.Fatalf("Wait returned error: %v", err)
+			}
+			if l.Allow() {
+				t.Error("expected the window to be full again")
+			}
+		})
+	}
+}
+
+func TestSetClock_ConcurrentUse(t *testing.T) {
+	limiters := map[string]interface {
+		Limiter
+		Wait(ctx context.Context) error
+		Status() Status
+		SetClock(c clock.Clock)
+	}{
+		"TokenBucket":          NewTokenBucket(1000, 1000),
+		"FixedWindow":          NewFixedWindow(1000, time.Second),
+		"SlidingWindowLog":     NewSlidingWindowLog(1000, time.Second),
+		"SlidingWindowCounter": NewSlidingWindowCounter(1000, time.Second),
+		"GCRA":                 NewGCRA(1000, time.Second, 1000),
+	}
+
+	for name, limiter := range limiters {
+		t.Run(name, func(t *testing.T) {
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				for range 100 {
+					limiter.SetClock(newFakeClock())
+					runtime.Gosched()
+					limiter.SetClock(nil)
+					runtime.Gosched()
+				}
+			}()
+
+			// Run with -race: the clock must only be read under the limiter's mutex
+			for range 100 {
+				limiter.Allow()
+				limiter.Status()
+				_ = limiter.Wait(context.Background())
+				runtime.Gosched()
+			}
+			<-done
+		})
+	}
+}
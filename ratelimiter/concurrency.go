@@ -0,0 +1,160 @@
+package ratelimiter
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"sync"
+)
+
+// ConcurrencyLimiter limits how many operations are in flight at once, such
+// as queries against a database. Each operation holds a weight, 1 for most,
+// from Acquire until Release. Waiters are served in arrival order, so a heavy
+// operation is not starved by a stream of light ones.
+type ConcurrencyLimiter struct {
+	mu      sync.Mutex
+	limit   int       // Maximum total weight in flight
+	inUse   int       // Total weight currently held
+	waiters list.List // Blocked Acquire calls, oldest first
+}
+
+// concurrencyWaiter is a blocked Acquire call.
+type concurrencyWaiter struct {
+	n     int
+	err   error         // Set if the waiter was rejected rather than granted
+	ready chan struct{} // Closed once the weight is granted or err is set
+}
+
+// NewConcurrencyLimiter creates a ConcurrencyLimiter that allows a total weight of limit in flight.
+// If limit < 1, it defaults to 1.
+func NewConcurrencyLimiter(limit int) *ConcurrencyLimiter {
+	if limit < 1 {
+		limit = 1
+	}
+
+	return &ConcurrencyLimiter{limit: limit}
+}
+
+// Acquire blocks until a weight of n is available and takes it, or until the context is cancelled.
+// Every successful Acquire must be followed by Release(n).
+// Returns an error if n exceeds the limit, or if the context is cancelled first.
+// Returns nil without taking anything if n is less or equal to 0.
+func (c *ConcurrencyLimiter) Acquire(ctx context.Context, n int) error {
+	if n <= 0 {
+		return nil
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	if n > c.limit {
+		limit := c.limit
+		c.mu.Unlock()
+
+		return fmt.Errorf("requested weight %d exceeds limit %d", n, limit)
+	}
+	if c.waiters.Len() == 0 && c.inUse+n <= c.limit {
+		c.inUse += n
+		c.mu.Unlock()
+
+		return nil
+	}
+
+	w := &concurrencyWaiter{n: n, ready: make(chan struct{})}
+	elem := c.waiters.PushBack(w)
+	c.mu.Unlock()
+
+	select {
+	case <-w.ready:
+		return w.err
+	case <-ctx.Done():
+		c.mu.Lock()
+		defer c.mu.Unlock()
+
+		select {
+		case <-w.ready:
+			// Rejected by SetLimit while the context was cancelled; report why
+			if w.err != nil {
+				return w.err
+			}
+			// Granted while the context was cancelled; give it back
+			c.inUse -= n
+			c.notify()
+		default:
+			c.waiters.Remove(elem)
+			// Waiters queued behind this one may fit now
+			c.notify()
+		}
+
+		return ctx.Err()
+	}
+}
+
+// TryAcquire takes a weight of n if it is available right away, without blocking.
+// Returns true if it was taken. Returns true if n is less or equal to 0.
+func (c *ConcurrencyLimiter) TryAcquire(n int) bool {
+	if n <= 0 {
+		return true
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.waiters.Len() == 0 && c.inUse+n <= c.limit {
+		c.inUse += n
+
+		return true
+	}
+
+	return false
+}
+
+// Release gives back a weight of n taken by Acquire or TryAcquire, waking the waiters that fit.
+// It panics if more weight is released than is held, which is always a bug in the caller.
+func (c *ConcurrencyLimiter) Release(n int) {
+	if n <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if n > c.inUse {
+		panic("ratelimiter: released more weight than held")
+	}
+	c.inUse -= n
+	c.notify()
+}
+
+// InUse returns the total weight currently held.
+func (c *ConcurrencyLimiter) InUse() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.inUse
+}
+
+// Limit returns the maximum total weight in flight.
+func (c *ConcurrencyLimiter) Limit() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.limit
+}
+
+// SetLimit updates the maximum total weight in flight.
+// Lowering it does not interrupt operations already in flight; new ones wait until enough are released.
+// Waiters for more than the new limit fail with an error.
+// If limit < 1, it defaults to 1.
+func (c *ConcurrencyLimiter) SetLimit(limit int) {
+	if limit < 1 {
+		limit = 1
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.limit = limit
+	c.notify()
+}
@@ -0,0 +1,271 @@
+package ratelimiter
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// waiting returns the number of blocked Acquire calls.
+func (c *ConcurrencyLimiter) waiting() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.waiters.Len()
+}
+
+// waitForWaiters blocks until n Acquire calls are queued in c.
+func waitForWaiters(c *ConcurrencyLimiter, n int) {
+	for c.waiting() < n {
+		runtime.Gosched()
+	}
+}
+
+func TestConcurrencyLimiter_AcquireRelease(t *testing.T) {
+	c := NewConcurrencyLimiter(3)
+
+	if err := c.Acquire(context.Background(), 2); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !c.TryAcquire(1) {
+		t.Fatal("expected TryAcquire(1) to fit the remaining weight")
+	}
+	if c.TryAcquire(1) {
+		t.Fatal("expected TryAcquire to fail at the limit")
+	}
+	if c.InUse() != 3 {
+		t.Errorf("expected 3 in use, got %d", c.InUse())
+	}
+
+	c.Release(2)
+	if c.InUse() != 1 {
+		t.Errorf("expected 1 in use after Release, got %d", c.InUse())
+	}
+	if !c.TryAcquire(2) {
+		t.Fatal("expected the released weight to be available")
+	}
+}
+
+func TestConcurrencyLimiter_Defaults(t *testing.T) {
+	c := NewConcurrencyLimiter(0)
+	if c.Limit() != 1 {
+		t.Errorf("expected limit 1, got %d", c.Limit())
+	}
+	if err := c.Acquire(context.Background(), 0); err != nil {
+		t.Errorf("expected Acquire(0) to succeed, got %v", err)
+	}
+	if !c.TryAcquire(-1) {
+		t.Error("expected TryAcquire(-1) to succeed")
+	}
+	if c.InUse() != 0 {
+		t.Errorf("expected nothing in use, got %d", c.InUse())
+	}
+}
+
+func TestConcurrencyLimiter_ExceedsLimit(t *testing.T) {
+	c := NewConcurrencyLimiter(2)
+	if err := c.Acquire(context.Background(), 3); err == nil {
+		t.Fatal("expected an error for a weight above the limit")
+	}
+}
+
+func TestConcurrencyLimiter_AcquireBlocksUntilRelease(t *testing.T) {
+	c := NewConcurrencyLimiter(1)
+	c.TryAcquire(1)
+
+	done := waitAsync(func(ctx context.Context) error { return c.Acquire(ctx, 1) })
+	waitForWaiters(c, 1)
+	expectBlocked(t, done)
+
+	c.Release(1)
+	if err := <-done; err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if c.InUse() != 1 {
+		t.Errorf("expected the waiter to hold the weight, got %d in use", c.InUse())
+	}
+}
+
+func TestConcurrencyLimiter_ContextCancelled(t *testing.T) {
+	c := NewConcurrencyLimiter(1)
+	c.TryAcquire(1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if err := c.Acquire(ctx, 1); err != context.DeadlineExceeded {
+		t.Fatalf("expected context.DeadlineExceeded, got %v", err)
+	}
+
+	c.Release(1)
+	if c.InUse() != 0 {
+		t.Errorf("expected the cancelled waiter to hold nothing, got %d in use", c.InUse())
+	}
+}
+
+func TestConcurrencyLimiter_FIFO(t *testing.T) {
+	c := NewConcurrencyLimiter(2)
+	c.TryAcquire(2)
+
+	heavy := waitAsync(func(ctx context.Context) error { return c.Acquire(ctx, 2) })
+	waitForWaiters(c, 1)
+	light := waitAsync(func(ctx context.Context) error { return c.Acquire(ctx, 1) })
+	waitForWaiters(c, 2)
+
+	if c.TryAcquire(1) {
+		t.Fatal("expected TryAcquire not to overtake waiters")
+	}
+
+	// Waiters are granted by Release itself, so both must still be queued
+	c.Release(1)
+	if c.waiting() != 2 {
+		t.Fatalf("expected the light waiter not to overtake the heavy one, got %d waiting", c.waiting())
+	}
+
+	c.Release(1)
+	if err := <-heavy; err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if c.waiting() != 1 {
+		t.Fatalf("expected the light waiter to still be queued, got %d waiting", c.waiting())
+	}
+	expectBlocked(t, light)
+
+	c.Release(2)
+	if err := <-light; err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestConcurrencyLimiter_CancelWakesWaitersBehind(t *testing.T) {
+	c := NewConcurrencyLimiter(2)
+	c.TryAcquire(1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	heavy := waitAsync(func(context.Context) error { return c.Acquire(ctx, 2) })
+	waitForWaiters(c, 1)
+	light := waitAsync(func(ctx context.Context) error { return c.Acquire(ctx, 1) })
+	waitForWaiters(c, 2)
+	expectBlocked(t, light)
+
+	cancel()
+	if err := <-heavy; err != context.Canceled {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if err := <-light; err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestConcurrencyLimiter_SetLimit(t *testing.T) {
+	c := NewConcurrencyLimiter(1)
+	c.TryAcquire(1)
+
+	first := waitAsync(func(ctx context.Context) error { return c.Acquire(ctx, 1) })
+	waitForWaiters(c, 1)
+	second := waitAsync(func(ctx context.Context) error { return c.Acquire(ctx, 1) })
+	waitForWaiters(c, 2)
+
+	c.SetLimit(2)
+	if err := <-first; err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectBlocked(t, second)
+
+	c.Release(1)
+	if err := <-second; err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	c.Release(2)
+	c.TryAcquire(2)
+	rejected := waitAsync(func(ctx context.Context) error { return c.Acquire(ctx, 2) })
+	waitForWaiters(c, 1)
+	c.SetLimit(1)
+	if err := <-rejected; err == nil {
+		t.Fatal("expected a waiter above the new limit to fail")
+	}
+}
+
+func TestConcurrencyLimiter_SetLimitRejectsCancelledWaiter(t *testing.T) {
+	c := NewConcurrencyLimiter(2)
+	c.TryAcquire(2)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := waitAsync(func(context.Context) error { return c.Acquire(ctx, 2) })
+	waitForWaiters(c, 1)
+
+	// Cancel and lower the limit at once, as SetLimit racing a cancellation
+	// would: the waiter sees both, and the rejection must not be lost
+	c.mu.Lock()
+	cancel()
+	c.limit = 1
+	c.notify()
+	c.mu.Unlock()
+
+	if err := <-done; err == nil || errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the limit error, got %v", err)
+	}
+}
+
+func TestConcurrencyLimiter_ReleaseTooMuchPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected Release to panic")
+		}
+	}()
+
+	NewConcurrencyLimiter(1).Release(1)
+}
+
+func TestConcurrencyLimiter_Concurrent(t *testing.T) {
+	c := NewConcurrencyLimiter(3)
+
+	var inFlight, peak atomic.Int32
+	var wg sync.WaitGroup
+	for range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			if err := c.Acquire(context.Background(), 1); err != nil {
+				t.Errorf("unexpected error: %v", err)
+
+				return
+			}
+			n := inFlight.Add(1)
+			for {
+				p := peak.Load()
+				if n <= p || peak.CompareAndSwap(p, n) {
+					break
+				}
+			}
+			time.Sleep(time.Millisecond)
+			inFlight.Add(-1)
+			c.Release(1)
+		}()
+	}
+	wg.Wait()
+
+	if peak.Load() > 3 {
+		t.Errorf("expected at most 3 in flight, got %d", peak.Load())
+	}
+	if c.InUse() != 0 {
+		t.Errorf("expected nothing in use, got %d", c.InUse())
+	}
+}
+
+func BenchmarkConcurrencyLimiter(b *testing.B) {
+	c := NewConcurrencyLimiter(8)
+	ctx := context.Background()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			_ = c.Acquire(ctx, 1)
+			c.Release(1)
+		}
+	})
+}
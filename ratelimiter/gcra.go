@@ -0,0 +1,240 @@
+package ratelimiter
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/kashifkhan0771/utils/clock"
+)
+
+// GCRA implements the generic cell rate algorithm, the leaky bucket used as a meter.
+// It allows 'limit' events per 'interval', spaced evenly one emission interval
+// (interval / limit) apart, and tolerates bursts of up to 'burst' events.
+// With a burst of 1, events are strictly paced.
+// Its only state is the theoretical arrival time of the next event, which
+// makes it cheap to keep one per client in a Keyed registry.
+type GCRA struct {
+	mu       sync.Mutex    // Mutex to protect concurrent access
+	limit    int           // Maximum allowed events per interval
+	interval time.Duration // Period over which limit events are spread
+	burst    int           // Maximum events allowed at once
+	tat      time.Time     // Theoretical arrival time of the next event
+	clock    clock.Clock   // Time source
+}
+
+// NewGCRA creates a new GCRA rate limiter that allows limit events per interval, with bursts of up to burst events.
+// If limit < 1, it defaults to 1. If interval <= 0, it defaults to 1 second. If burst < 1, it defaults to 1.
+func NewGCRA(limit int, interval time.Duration, burst int) *GCRA {
+	if limit < 1 {
+		limit = 1
+	}
+	if interval <= 0 {
+		interval = 1 * time.Second
+	}
+	if burst < 1 {
+		burst = 1
+	}
+
+	return &GCRA{
+		limit:    limit,
+		interval: interval,
+		burst:    burst,
+		clock:    clock.New(),
+	}
+}
+
+// Allow checks if a new event conforms to the rate and records it if so.
+// This is a convenience method that calls AllowN(1).
+func (g *GCRA) Allow() bool {
+	return g.AllowN(1)
+}
+
+// AllowN checks if n events conform to the rate and records them atomically.
+// Returns false if n exceeds the burst. Returns true if n is less or equal to 0.
+// This method is thread-safe and non-blocking.
+func (g *GCRA) AllowN(n int) bool {
+	if n <= 0 {
+		return true
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := g.clock.Now()
+	if n > g.burst {
+		return false
+	}
+
+	tat, d := g.schedule(now, n)
+	if d > 0 {
+		return false
+	}
+	g.tat = tat
+
+	return true
+}
+
+// Wait blocks until a new event conforms to the rate and records it, or until the context is cancelled.
+// This is a convenience method that calls WaitN(ctx, 1).
+func (g *GCRA) Wait(ctx context.Context) error {
+	return g.WaitN(ctx, 1)
+}
+
+// WaitN blocks until n events conform to the rate and records them, or until the context is cancelled.
+// The events are reserved as soon as WaitN is called, so waiters are served in order,
+// evenly spaced, and are given back if the context is cancelled first.
+// Returns an error if n exceeds the burst, or if the context is cancelled.
+func (g *GCRA) WaitN(ctx context.Context, n int) error {
+	if n <= 0 {
+		return nil
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r := g.ReserveN(n)
+	if !r.OK() {
+		g.mu.Lock()
+		burst := g.burst
+		g.mu.Unlock()
+
+		return fmt.Errorf("requested events %d exceeds burst %d", n, burst)
+	}
+
+	d := r.Delay()
+	if d == 0 {
+		return nil
+	}
+
+	timer := r.clock.NewTimer(d)
+	select {
+	case <-ctx.Done():
+		timer.Stop()
+		r.Cancel()
+
+		return ctx.Err()
+	case <-timer.C():
+		return nil
+	}
+}
+
+// Reserve reserves a new event and returns a Reservation telling how long to wait before it may happen.
+// This is a convenience method that calls ReserveN(1).
+func (g *GCRA) Reserve() *Reservation {
+	return g.ReserveN(1)
+}
+
+// ReserveN reserves n events at the first time they conform to the rate and
+// returns a Reservation telling how long to wait until then.
+// The Reservation is not OK if n exceeds the burst, in which case nothing is reserved.
+// Returns an OK reservation with no delay if n is less or equal to 0.
+func (g *GCRA) ReserveN(n int) *Reservation {
+	if n <= 0 {
+		return &Reservation{ok: true}
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := g.clock.Now()
+	if n > g.burst {
+		return &Reservation{}
+	}
+
+	tat, d := g.schedule(now, n)
+	g.tat = tat
+	timeToAct := now.Add(max(d, 0))
+
+	return &Reservation{
+		ok:        true,
+		timeToAct: timeToAct,
+		clock:     g.clock,
+		cancel:    func() { g.refund(n, timeToAct) },
+	}
+}
+
+// Status reports the burst, the events that conform to the rate right now and
+// how long until a full burst is allowed again.
+// This method is thread-safe and does not record any event.
+func (g *GCRA) Status() Status {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := g.clock.Now()
+	emission := g.emission()
+	backlog := max(g.tat.Sub(now), 0)
+
+	status := Status{
+		Limit:     g.burst,
+		Remaining: min(int((time.Duration(g.burst)*emission-backlog)/emission), g.burst),
+		Reset:     backlog,
+	}
+	if status.Remaining <= 0 {
+		status.Remaining = 0
+		_, status.RetryAfter = g.schedule(now, 1)
+	}
+
+	return status
+}
+
+// SetLimit updates the maximum allowed events per interval.
+// Events already recorded are spread at the new rate.
+// If limit <= 0, it defaults to 1.
+func (g *GCRA) SetLimit(limit int) {
+	if limit <= 0 {
+		limit = 1
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := g.clock.Now()
+	old := g.emission()
+	g.limit = limit
+	g.rescale(now, old)
+}
+
+// SetInterval updates the period over which limit events are spread.
+// Events already recorded are spread at the new rate.
+// If interval <= 0, it defaults to 1 second.
+func (g *GCRA) SetInterval(interval time.Duration) {
+	if interval <= 0 {
+		interval = 1 * time.Second
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := g.clock.Now()
+	old := g.emission()
+	g.interval = interval
+	g.rescale(now, old)
+}
+
+// SetBurst updates the maximum events allowed at once.
+// If burst <= 0, it defaults to 1.
+func (g *GCRA) SetBurst(burst int) {
+	if burst <= 0 {
+		burst = 1
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.burst = burst
+}
+
+// SetClock replaces the time source of the rate limiter, e.g. with a fake clock in tests.
+// Events already recorded are carried over relative to the current time of c.
+// A nil c restores the real clock.
+func (g *GCRA) SetClock(c clock.Clock) {
+	c = clock.OrDefault(c)
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	backlog := max(g.tat.Sub(g.clock.Now()), 0)
+	g.clock = c
+	g.tat = c.Now().Add(backlog)
+}
@@ -0,0 +1,326 @@
+package ratelimiter
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kashifkhan0771/utils/clock"
+)
+
+// newTestGCRA returns a GCRA driven by a fake clock.
+func newTestGCRA(limit int, interval time.Duration, burst int) (*GCRA, *clock.Fake) {
+	clk := newFakeClock()
+	g := NewGCRA(limit, interval, burst)
+	g.SetClock(clk)
+
+	return g, clk
+}
+
+func TestNewGCRA(t *testing.T) {
+	g := NewGCRA(0, 0, 0)
+	if g.limit != 1 || g.interval != time.Second || g.burst != 1 {
+		t.Errorf("expected defaults 1, 1s, 1, got %d, %v, %d", g.limit, g.interval, g.burst)
+	}
+	if !g.Allow() {
+		t.Error("expected the first event to be allowed")
+	}
+}
+
+func TestGCRA_Pacing(t *testing.T) {
+	// One event every 100ms, no burst
+	g, clk := newTestGCRA(10, time.Second, 1)
+
+	if !g.Allow() {
+		t.Fatal("expected the first event to be allowed")
+	}
+	if g.Allow() {
+		t.Fatal("expected the second event to wait for the emission interval")
+	}
+
+	clk.Advance(99 * time.Millisecond)
+	if g.Allow() {
+		t.Fatal("expected no event before 100ms")
+	}
+	clk.Advance(time.Millisecond)
+	if !g.Allow() {
+		t.Fatal("expected an event after 100ms")
+	}
+
+	// Idle time does not build up credit beyond the burst
+	clk.Advance(time.Second)
+	if !g.Allow() {
+		t.Fatal("expected an event after idling")
+	}
+	if g.Allow() {
+		t.Error("expected idling not to allow a burst")
+	}
+}
+
+func TestGCRA_Burst(t *testing.T) {
+	g, clk := newTestGCRA(10, time.Second, 3)
+
+	if !g.AllowN(3) {
+		t.Fatal("expected a full burst to be allowed")
+	}
+	if g.Allow() {
+		t.Fatal("expected the burst to be used up")
+	}
+
+	// The burst refills one event per emission interval
+	clk.Advance(100 * time.Millisecond)
+	if !g.Allow() {
+		t.Fatal("expected one event after an emission interval")
+	}
+	if g.Allow() {
+		t.Fatal("expected only one event after an emission interval")
+	}
+
+	clk.Advance(300 * time.Millisecond)
+	if !g.AllowN(3) {
+		t.Fatal("expected a full burst after three emission intervals")
+	}
+}
+
+func TestGCRA_AllowNEdgeCases(t *testing.T) {
+	g, _ := newTestGCRA(10, time.Second, 2)
+
+	if !g.AllowN(0) || !g.AllowN(-1) {
+		t.Error("expected AllowN to allow n <= 0")
+	}
+	if g.AllowN(3) {
+		t.Error("expected AllowN to deny more than the burst")
+	}
+	if !g.AllowN(2) {
+		t.Error("expected the denied calls not to record events")
+	}
+}
+
+func TestGCRA_ReserveN(t *testing.T) {
+	g, clk := newTestGCRA(10, time.Second, 2)
+
+	delays := []time.Duration{0, 0, 100 * time.Millisecond, 200 * time.Millisecond}
+	for i, want := range delays {
+		r := g.Reserve()
+		if !r.OK() {
+			t.Fatalf("reservation %d: expected OK", i)
+		}
+		if r.Delay() != want {
+			t.Errorf("reservation %d: expected a delay of %v, got %v", i, want, r.Delay())
+		}
+	}
+
+	clk.Advance(50 * time.Millisecond)
+	// 350ms of reservations remain, plus 200ms for two events, minus a burst of 200ms
+	if r := g.ReserveN(2); r.Delay() != 350*time.Millisecond {
+		t.Errorf("expected a delay of 350ms, got %v", r.Delay())
+	}
+
+	if r := g.ReserveN(3); r.OK() || r.Delay() != InfDuration {
+		t.Error("expected a reservation above the burst not to be OK")
+	}
+	if r := g.ReserveN(0); !r.OK() || r.Delay() != 0 {
+		t.Error("expected an OK reservation with no delay for n <= 0")
+	}
+}
+
+func TestGCRA_ReservationCancel(t *testing.T) {
+	g, clk := newTestGCRA(10, time.Second, 1)
+	g.Allow()
+
+	r := g.Reserve()
+	if r.Delay() != 100*time.Millisecond {
+		t.Fatalf("expected a delay of 100ms, got %v", r.Delay())
+	}
+	r.Cancel()
+	r.Cancel()
+
+	clk.Advance(100 * time.Millisecond)
+	if !g.Allow() {
+		t.Fatal("expected the cancelled event to be given back")
+	}
+	if g.Allow() {
+		t.Fatal("expected a second Cancel to have no effect")
+	}
+
+	// A reservation that is due can't be cancelled
+	clk.Advance(100 * time.Millisecond)
+	r = g.Reserve()
+	r.Cancel()
+	if g.Allow() {
+		t.Error("expected cancelling a due reservation to give nothing back")
+	}
+}
+
+func TestGCRA_WaitN(t *testing.T) {
+	g, clk := newTestGCRA(10, time.Second, 1)
+	g.Allow()
+
+	first := waitAsync(g.Wait)
+	clk.WaitForTimers(1)
+	second := waitAsync(g.Wait)
+	clk.WaitForTimers(2)
+
+	clk.Advance(100 * time.Millisecond)
+	if err := <-first; err != nil {
+		t.Fatalf("Wait returned error: %v", err)
+	}
+	expectBlocked(t, second)
+
+	clk.Advance(100 * time.Millisecond)
+	if err := <-second; err != nil {
+		t.Fatalf("Wait returned error: %v", err)
+	}
+
+	if err := g.WaitN(context.Background(), 2); err == nil {
+		t.Error("expected an error for more events than the burst")
+	}
+	if err := g.WaitN(context.Background(), 0); err != nil {
+		t.Errorf("expected WaitN(0) to return nil, got %v", err)
+	}
+}
+
+func TestGCRA_WaitContextCancel(t *testing.T) {
+	g, clk := newTestGCRA(1, time.Minute, 1)
+	g.Allow()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := waitAsync(func(context.Context) error { return g.Wait(ctx) })
+	clk.WaitForTimers(1)
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+
+	// The cancelled wait gave its event back
+	clk.Advance(time.Minute)
+	if !g.Allow() {
+		t.Error("expected an event after the emission interval")
+	}
+
+	if err := g.Wait(ctx); err != context.Canceled {
+		t.Errorf("expected context.Canceled for a cancelled context, got %v", err)
+	}
+}
+
+func TestGCRA_Status(t *testing.T) {
+	g, clk := newTestGCRA(10, time.Second, 3)
+
+	if s := g.Status(); s != (Status{Limit: 3, Remaining: 3}) {
+		t.Errorf("unexpected status of a fresh limiter: %+v", s)
+	}
+
+	g.AllowN(2)
+	want := Status{Limit: 3, Remaining: 1, Reset: 200 * time.Millisecond}
+	if s := g.Status(); s != want {
+		t.Errorf("expected %+v, got %+v", want, s)
+	}
+
+	g.Allow()
+	g.Reserve()
+	want = Status{Limit: 3, Remaining: 0, Reset: 400 * time.Millisecond, RetryAfter: 200 * time.Millisecond}
+	if s := g.Status(); s != want {
+		t.Errorf("expected %+v, got %+v", want, s)
+	}
+
+	clk.Advance(250 * time.Millisecond)
+	want = Status{Limit: 3, Remaining: 1, Reset: 150 * time.Millisecond}
+	if s := g.Status(); s != want {
+		t.Errorf("expected %+v, got %+v", want, s)
+	}
+}
+
+func TestGCRA_Setters(t *testing.T) {
+	g, clk := newTestGCRA(10, time.Second, 1)
+	g.Allow()
+
+	// The pending 100ms interval becomes 50ms at twice the rate
+	g.SetLimit(20)
+	clk.Advance(50 * time.Millisecond)
+	if !g.Allow() {
+		t.Fatal("expected an event after the new emission interval")
+	}
+
+	// Back to 100ms
+	g.SetInterval(2 * time.Second)
+	clk.Advance(99 * time.Millisecond)
+	if g.Allow() {
+		t.Fatal("expected no event before the new emission interval")
+	}
+	clk.Advance(time.Millisecond)
+
+	g.SetBurst(3)
+	if !g.AllowN(3) {
+		t.Fatal("expected a burst of 3 after SetBurst")
+	}
+
+	g.SetLimit(0)
+	g.SetInterval(0)
+	g.SetBurst(0)
+	if g.limit != 1 || g.interval != time.Second || g.burst != 1 {
+		t.Errorf("expected setters to default to 1, 1s, 1, got %d, %v, %d", g.limit, g.interval, g.burst)
+	}
+}
+
+func TestGCRA_SetClock(t *testing.T) {
+	g, clk := newTestGCRA(1, time.Minute, 1)
+	g.Allow()
+	clk.Advance(20 * time.Second)
+
+	// The remaining 40s carry over to the new clock
+	other := clock.NewFake(time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC))
+	g.SetClock(other)
+	other.Advance(39 * time.Second)
+	if g.Allow() {
+		t.Fatal("expected no event before the carried over interval")
+	}
+	other.Advance(time.Second)
+	if !g.Allow() {
+		t.Fatal("expected an event after the carried over interval")
+	}
+
+	g.SetClock(nil)
+	if _, ok := g.clock.(*clock.Fake); ok {
+		t.Error("expected SetClock(nil) to restore the real clock")
+	}
+}
+
+func TestGCRA_ConcurrentAccess(t *testing.T) {
+	g, clk := newTestGCRA(100, time.Second, 10)
+
+	var mu sync.Mutex
+	allowed := 0
+	var wg sync.WaitGroup
+	for range 50 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			if g.Allow() {
+				mu.Lock()
+				allowed++
+				mu.Unlock()
+			}
+			_ = g.Status()
+		}()
+	}
+	wg.Wait()
+
+	if allowed != 10 {
+		t.Errorf("expected exactly the burst of 10 to be allowed, got %d", allowed)
+	}
+	clk.Advance(10 * time.Millisecond)
+	if !g.Allow() {
+		t.Error("expected an event after the emission interval")
+	}
+}
+
+func BenchmarkGCRA_Allow(b *testing.B) {
+	g := NewGCRA(1_000_000, time.Second, 1000)
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			g.Allow()
+		}
+	})
+}
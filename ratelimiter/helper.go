@@ -1,6 +1,14 @@
 package ratelimiter
 
-import "time"
+import (
+	"context"
+	"fmt"
+	"hash/maphash"
+	"math"
+	"time"
+
+	"github.com/kashifkhan0771/utils/clock"
+)
 
 // refill adds tokens according to elapsed time.
 // Caller must hold the mutex
@@ -50,3 +58,410 @@ func (t *TokenBucket) nextAvailableDuration(n int) time.Duration {
 
 	return time.Duration(secs * float64(time.Second))
 }
+
+// waitN blocks until try allows n events, or until the context is cancelled.
+// try records the events and returns true if they are allowed, or else how
+// long to wait before trying again. It also returns the current limit and the
+// clock to wait on, both read under the limiter's mutex.
+func waitN(ctx context.Context, n int, try func() (bool, time.Duration, int, clock.Clock)) error {
+	for {
+		ok, d, limit, c := try()
+		if ok {
+			return nil
+		}
+		if n > limit {
+			return fmt.Errorf("requested events %d exceeds limit %d", n, limit)
+		}
+
+		// Rounding can leave the events unavailable once d has passed; wait
+		// at least a millisecond to prevent busy-waiting.
+		timer := c.NewTimer(max(d, time.Millisecond))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+
+			return ctx.Err()
+		case <-timer.C():
+			// Timer expired, loop to try again
+		}
+	}
+}
+
+// allowN records n events if they fit in the window ending at now.
+// Caller must hold the mutex.
+func (l *SlidingWindowLog) allowN(now time.Time, n int) bool {
+	l.prune(now)
+
+	if l.count+n > l.limit {
+		return false
+	}
+
+	if last := len(l.events) - 1; last >= 0 && l.events[last].at.Equal(now) {
+		l.events[last].n += n
+	} else {
+		l.events = append(l.events, windowEvent{at: now, n: n})
+	}
+	l.count += n
+
+	return true
+}
+
+// prune drops the events that are no longer inside the window ending at now.
+// Caller must hold the mutex.
+func (l *SlidingWindowLog) prune(now time.Time) {
+	cutoff := now.Add(-l.interval)
+
+	i := 0
+	for i < len(l.events) && !l.events[i].at.After(cutoff) {
+		l.count -= l.events[i].n
+		i++
+	}
+
+	if i == len(l.events) {
+		// Reuse the backing array once the window is empty
+		l.events = l.events[:0]
+
+		return
+	}
+	l.events = l.events[i:]
+}
+
+// nextAvailableDuration returns how long from now until n more events fit in the window.
+// Returns 0 if n is larger than the limit; callers check that separately.
+// Caller must hold the mutex and have pruned the log.
+func (l *SlidingWindowLog) nextAvailableDuration(now time.Time, n int) time.Duration {
+	excess := l.count + n - l.limit
+	if n > l.limit || excess <= 0 {
+		return 0
+	}
+
+	for _, e := range l.events {
+		excess -= e.n
+		if excess <= 0 {
+			return e.at.Add(l.interval).Sub(now)
+		}
+	}
+
+	return 0
+}
+
+// allowN records n events if the weighted count leaves room for them.
+// Caller must hold the mutex.
+func (l *SlidingWindowCounter) allowN(now time.Time, n int) bool {
+	l.advance(now)
+
+	if l.estimate(now)+float64(n) > float64(l.limit) {
+		return false
+	}
+	l.currCount += n
+
+	return true
+}
+
+// advance moves the current window forward so that it contains now.
+// Caller must hold the mutex.
+func (l *SlidingWindowCounter) advance(now time.Time) {
+	if now.Before(l.windowStart) {
+		// Clock moved backwards, start a fresh window
+		l.windowStart = now
+		l.prevCount = l.currCount
+		l.currCount = 0
+
+		return
+	}
+
+	windows := now.Sub(l.windowStart) / l.interval
+	if windows == 0 {
+		return
+	}
+
+	if windows == 1 {
+		l.prevCount = l.currCount
+	} else {
+		l.prevCount = 0
+	}
+	l.currCount = 0
+	l.windowStart = l.windowStart.Add(windows * l.interval)
+}
+
+// estimate returns the weighted number of events in the sliding window ending at now.
+// Caller must hold the mutex and have advanced the window.
+func (l *SlidingWindowCounter) estimate(now time.Time) float64 {
+	overlap := 1 - float64(now.Sub(l.windowStart))/float64(l.interval)
+
+	return float64(l.prevCount)*overlap + float64(l.currCount)
+}
+
+// nextAvailableDuration returns how long from now until n more events are allowed.
+// Returns 0 if n is larger than the limit; callers check that separately.
+// Caller must hold the mutex and have advanced the window.
+func (l *SlidingWindowCounter) nextAvailableDuration(now time.Time, n int) time.Duration {
+	if n > l.limit {
+		return 0
+	}
+
+	room := float64(l.limit - l.currCount - n)
+	if room < 0 || l.prevCount == 0 {
+		// Not enough room before the current window ends
+		return l.windowStart.Add(l.interval).Sub(now)
+	}
+
+	// Wait until the previous window's weight has decayed enough:
+	// prevCount * (1 - elapsed/interval) <= room
+	elapsed := time.Duration((1 - room/float64(l.prevCount)) * float64(l.interval))
+
+	return max(elapsed-now.Sub(l.windowStart), 0)
+}
+
+// shard returns the shard that key belongs to.
+func (k *Keyed[K, L]) shard(key K) *keyedShard[K, L] {
+	return k.shards[maphash.Comparable(k.seed, key)%uint64(len(k.shards))]
+}
+
+// get returns the entry of key and its limiter, creating the entry if needed.
+func (s *keyedShard[K, L]) get(key K, now time.Time, ttl time.Duration, newLimiter func(K) L) (*keyedEntry[K, L], L) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[key]
+	if ok && s.expired(entry, now, ttl) {
+		// Idle for too long, start over with a fresh limiter
+		s.remove(entry)
+		ok = false
+	}
+
+	if ok {
+		if entry.elem != nil {
+			s.lru.MoveToFront(entry.elem)
+		}
+		entry.lastSeen = now
+
+		return entry, entry.limiter
+	}
+
+	s.evictIdle(now, ttl)
+	for s.maxKeys > 0 && s.lru.Len() >= s.maxKeys {
+		oldest, _ := s.lru.Back().Value.(*keyedEntry[K, L])
+		s.remove(oldest)
+	}
+
+	entry = &keyedEntry[K, L]{key: key, limiter: newLimiter(key), lastSeen: now}
+	entry.elem = s.lru.PushFront(entry)
+	s.entries[key] = entry
+
+	return entry, entry.limiter
+}
+
+// expired reports whether entry has been idle for longer than ttl.
+// Overridden entries never expire. Caller must hold the mutex.
+func (s *keyedShard[K, L]) expired(entry *keyedEntry[K, L], now time.Time, ttl time.Duration) bool {
+	return ttl > 0 && entry.elem != nil && now.Sub(entry.lastSeen) > ttl
+}
+
+// evictIdle removes the entries idle for longer than ttl and returns how many were removed.
+// Caller must hold the mutex.
+func (s *keyedShard[K, L]) evictIdle(now time.Time, ttl time.Duration) int {
+	if ttl <= 0 {
+		return 0
+	}
+
+	n := 0
+	// The LRU list is ordered by last use, so idle entries are at the back
+	for elem := s.lru.Back(); elem != nil; elem = s.lru.Back() {
+		entry, _ := elem.Value.(*keyedEntry[K, L])
+		if !s.expired(entry, now, ttl) {
+			break
+		}
+		s.remove(entry)
+		n++
+	}
+
+	return n
+}
+
+// remove deletes entry from the shard. Caller must hold the mutex.
+func (s *keyedShard[K, L]) remove(entry *keyedEntry[K, L]) {
+	if entry.elem != nil {
+		s.lru.Remove(entry.elem)
+	}
+	delete(s.entries, entry.key)
+}
+
+// allow is Allow, also returning the limiter of key.
+func (k *Keyed[K, L]) allow(key K) (L, bool) {
+	entry, limiter := k.shard(key).get(key, k.clock.Now(), k.idleTTL, k.newLimiter)
+
+	if limiter.Allow() {
+		entry.allowed.Add(1)
+
+		return limiter, true
+	}
+	entry.denied.Add(1)
+
+	return limiter, false
+}
+
+// ceilSeconds returns d in whole seconds, rounded up.
+func ceilSeconds(d time.Duration) int {
+	if d <= 0 {
+		return 0
+	}
+
+	return int(math.Ceil(d.Seconds()))
+}
+
+// refund gives n reserved tokens back to the bucket, unless they were due by now.
+func (t *TokenBucket) refund(n int, timeToAct time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.clock.Now()
+	if !now.Before(timeToAct) {
+		return
+	}
+	t.refill(now)
+
+	t.tokens = min(t.tokens+float64(n), float64(t.capacity))
+}
+
+// advance starts a new window if the current one has ended. Windows holding
+// reserved events follow each other back to back; otherwise the new window
+// starts now. Caller must hold the mutex.
+func (l *FixedWindow) advance(now time.Time) {
+	for now.After(l.windowEndTime) {
+		if len(l.ahead) == 0 {
+			l.count = 0
+			l.windowEndTime = now.Add(l.interval)
+
+			return
+		}
+
+		l.count = l.ahead[0]
+		l.ahead = l.ahead[1:]
+		l.windowEndTime = l.windowEndTime.Add(l.interval)
+	}
+}
+
+// refund gives n events reserved in the window ending at windowEnd back, unless they were due by now.
+func (l *FixedWindow) refund(n int, windowEnd, timeToAct time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.clock.Now()
+	if !now.Before(timeToAct) {
+		return
+	}
+	l.advance(now)
+
+	if windowEnd.Equal(l.windowEndTime) {
+		l.count = max(l.count-n, 0)
+
+		return
+	}
+
+	for i := range l.ahead {
+		if l.windowEndTime.Add(time.Duration(i+1) * l.interval).Equal(windowEnd) {
+			l.ahead[i] = max(l.ahead[i]-n, 0)
+
+			break
+		}
+	}
+	// Drop windows left without reservations
+	for len(l.ahead) > 0 && l.ahead[len(l.ahead)-1] == 0 {
+		l.ahead = l.ahead[:len(l.ahead)-1]
+	}
+}
+
+// notify grants waiting Acquire calls in order, as long as their weight fits.
+// Waiters for more than the limit are rejected. Caller must hold the mutex.
+func (c *ConcurrencyLimiter) notify() {
+	for elem := c.waiters.Front(); elem != nil; elem = c.waiters.Front() {
+		w, _ := elem.Value.(*concurrencyWaiter)
+
+		switch {
+		case w.n > c.limit:
+			w.err = fmt.Errorf("requested weight %d exceeds limit %d", w.n, c.limit)
+		case c.inUse+w.n <= c.limit:
+			c.inUse += w.n
+		default:
+			// Keep the order: later waiters must not overtake this one
+			return
+		}
+
+		c.waiters.Remove(elem)
+		close(w.ready)
+	}
+}
+
+// record adjusts the limit after an operation that started with inFlight
+// operations in flight (itself included) and took latency.
+func (a *AdaptiveLimiter) record(inFlight int, latency time.Duration, err error) {
+	a.mu.Lock()
+
+	from := a.limit
+	switch {
+	case (err != nil && a.isDrop(err)) || (a.threshold > 0 && latency > a.threshold):
+		a.limit = max(int(float64(a.limit)*a.backoffRatio), a.minLimit)
+	case err == nil && inFlight*2 >= a.limit:
+		// Only grow while at least half the limit is in use, so an idle
+		// period does not inflate the limit
+		a.limit = min(a.limit+1, a.maxLimit)
+	}
+	to := a.limit
+	if from != to {
+		a.limiter.SetLimit(to)
+	}
+	a.mu.Unlock()
+
+	if from != to && a.onLimitChange != nil {
+		a.onLimitChange(from, to)
+	}
+}
+
+// emission returns the time between two evenly spaced events.
+// Caller must hold the mutex.
+func (g *GCRA) emission() time.Duration {
+	return max(g.interval/time.Duration(g.limit), 1)
+}
+
+// schedule returns the theoretical arrival time after n more events, and how long
+// from now until they conform to the rate, which is <= 0 if they do right away.
+// Caller must hold the mutex.
+func (g *GCRA) schedule(now time.Time, n int) (time.Time, time.Duration) {
+	emission := g.emission()
+	tat := g.tat
+	if tat.Before(now) {
+		tat = now
+	}
+	tat = tat.Add(time.Duration(n) * emission)
+
+	// Conforming events may arrive up to a burst of emission intervals early
+	return tat, tat.Add(-time.Duration(g.burst) * emission).Sub(now)
+}
+
+// rescale spreads the events still ahead of now at the current emission
+// interval instead of old. Caller must hold the mutex.
+func (g *GCRA) rescale(now time.Time, old time.Duration) {
+	if !g.tat.After(now) {
+		return
+	}
+
+	events := float64(g.tat.Sub(now)) / float64(old)
+	g.tat = now.Add(time.Duration(events * float64(g.emission())))
+}
+
+// refund gives n reserved events back, unless they were due by now.
+func (g *GCRA) refund(n int, timeToAct time.Time) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := g.clock.Now()
+	if !now.Before(timeToAct) {
+		return
+	}
+
+	g.tat = g.tat.Add(-time.Duration(n) * g.emission())
+	if g.tat.Before(now) {
+		g.tat = now
+	}
+}
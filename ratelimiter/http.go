@@ -0,0 +1,141 @@
+package ratelimiter
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"strings"
+)
+
+// KeyFunc returns the key a request is rate limited by, such as the client IP.
+type KeyFunc func(r *http.Request) string
+
+// MiddlewareOptions configures the HTTP middleware.
+type MiddlewareOptions struct {
+	KeyFunc   KeyFunc      // Extracts the key of a request. Defaults to RemoteIP()
+	OnLimited http.Handler // Responds to rejected requests, after the headers are set. Defaults to a plain 429 response
+}
+
+// Middleware returns HTTP middleware that limits requests with the limiter of
+// the request's key in limiters. Rejected requests get a 429 Too Many Requests
+// response with a Retry-After header. If the limiters implement
+// StatusReporter, every response also carries the RateLimit-Limit,
+// RateLimit-Remaining and RateLimit-Reset headers of the IETF
+// RateLimit header fields draft.
+// To apply a single limit to all requests, use a KeyFunc that returns a constant.
+func Middleware[L Limiter](limiters *Keyed[string, L], opts MiddlewareOptions) func(http.Handler) http.Handler {
+	keyFunc := opts.KeyFunc
+	if keyFunc == nil {
+		keyFunc = RemoteIP()
+	}
+	onLimited := opts.OnLimited
+	if onLimited == nil {
+		onLimited = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+		})
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			limiter, ok := limiters.allow(keyFunc(r))
+
+			var status Status
+			reporter, hasStatus := any(limiter).(StatusReporter)
+			if hasStatus {
+				status = reporter.Status()
+				h := w.Header()
+				h.Set("RateLimit-Limit", strconv.Itoa(status.Limit))
+				h.Set("RateLimit-Remaining", strconv.Itoa(status.Remaining))
+				h.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(status.Reset)))
+			}
+
+			if !ok {
+				retryAfter := 1
+				if hasStatus {
+					retryAfter = max(ceilSeconds(status.RetryAfter), 1)
+				}
+				w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+				onLimited.ServeHTTP(w, r)
+
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RemoteIP returns a KeyFunc that uses the IP address of the client.
+// If the request comes from one of the trusted proxies, the client IP is taken
+// from the X-Forwarded-For header: it is the rightmost address that is not a
+// trusted proxy, since any address to the left of it could have been forged.
+// Without trusted proxies, X-Forwarded-For is ignored.
+func RemoteIP(trustedProxies ...netip.Prefix) KeyFunc {
+	trusted := func(addr netip.Addr) bool {
+		for _, p := range trustedProxies {
+			if p.Contains(addr) {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	return func(r *http.Request) string {
+		host, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			host = r.RemoteAddr
+		}
+
+		addr, err := netip.ParseAddr(host)
+		if err != nil {
+			return host
+		}
+		addr = addr.Unmap()
+		if len(trustedProxies) == 0 || !trusted(addr) {
+			return addr.String()
+		}
+
+		forwarded := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+		for i := len(forwarded) - 1; i >= 0; i-- {
+			hop, err := netip.ParseAddr(strings.TrimSpace(forwarded[i]))
+			if err != nil {
+				// Unparsable entries can't be trusted, stop at the last good address
+				break
+			}
+			addr = hop.Unmap()
+			if !trusted(addr) {
+				break
+			}
+		}
+
+		return addr.String()
+	}
+}
+
+// HeaderKey returns a KeyFunc that uses the value of the named request header,
+// such as an API key. Requests without the header share the empty key.
+func HeaderKey(name string) KeyFunc {
+	return func(r *http.Request) string {
+		return r.Header.Get(name)
+	}
+}
+
+// ContextKey returns a KeyFunc that uses a value stored in the request context,
+// such as the subject set by an authentication middleware. Strings are used
+// as-is and other values are formatted with fmt. Requests without the value
+// share the empty key.
+func ContextKey(key any) KeyFunc {
+	return func(r *http.Request) string {
+		switch v := r.Context().Value(key).(type) {
+		case nil:
+			return ""
+		case string:
+			return v
+		default:
+			return fmt.Sprint(v)
+		}
+	}
+}
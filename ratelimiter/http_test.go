@@ -0,0 +1,221 @@
+package ratelimiter
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+)
+
+func okHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+}
+
+func TestMiddleware_Headers(t *testing.T) {
+	limiters := NewKeyed(func(string) *FixedWindow { return NewFixedWindow(2, 30*time.Second) }, KeyedOptions{})
+	handler := Middleware(limiters, MiddlewareOptions{})(okHandler())
+
+	tests := []struct {
+		remoteAddr string
+		code       int
+		remaining  string
+	}{
+		{"192.0.2.1:1234", http.StatusOK, "1"},
+		{"192.0.2.1:1235", http.StatusOK, "0"},
+		{"192.0.2.1:1236", http.StatusTooManyRequests, "0"},
+		{"192.0.2.2:1234", http.StatusOK, "1"}, // another client
+	}
+	for i, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = tt.remoteAddr
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if rec.Code != tt.code {
+			t.Errorf("request %d: code = %d, want %d", i, rec.Code, tt.code)
+		}
+		if got := rec.Header().Get("RateLimit-Limit"); got != "2" {
+			t.Errorf("request %d: RateLimit-Limit = %q, want 2", i, got)
+		}
+		if got := rec.Header().Get("RateLimit-Remaining"); got != tt.remaining {
+			t.Errorf("request %d: RateLimit-Remaining = %q, want %s", i, got, tt.remaining)
+		}
+		if got := rec.Header().Get("RateLimit-Reset"); got != "30" {
+			t.Errorf("request %d: RateLimit-Reset = %q, want 30", i, got)
+		}
+
+		retryAfter := rec.Header().Get("Retry-After")
+		if tt.code == http.StatusTooManyRequests && retryAfter != "30" {
+			t.Errorf("request %d: Retry-After = %q, want 30", i, retryAfter)
+		}
+		if tt.code == http.StatusOK && retryAfter != "" {
+			t.Errorf("request %d: unexpected Retry-After %q", i, retryAfter)
+		}
+	}
+}
+
+// allowOnce is a Limiter that does not report its status.
+type allowOnce struct{ used bool }
+
+func (a *allowOnce) Allow() bool {
+	allowed := !a.used
+	a.used = true
+
+	return allowed
+}
+
+func TestMiddleware_CustomLimiterAndOnLimited(t *testing.T) {
+	limiters := NewKeyed(func(string) *allowOnce { return &allowOnce{} }, KeyedOptions{})
+	handler := Middleware(limiters, MiddlewareOptions{
+		KeyFunc: func(*http.Request) string { return "global" },
+		OnLimited: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}),
+	})(okHandler())
+
+	for i, want := range []int{http.StatusOK, http.StatusServiceUnavailable} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		if rec.Code != want {
+			t.Errorf("request %d: code = %d, want %d", i, rec.Code, want)
+		}
+		if rec.Header().Get("RateLimit-Limit") != "" {
+			t.Errorf("request %d: expected no RateLimit headers without a StatusReporter", i)
+		}
+	}
+	if stats, _ := limiters.Stats("global"); stats.Allowed != 1 || stats.Denied != 1 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+}
+
+func TestMiddleware_RetryAfterTokenBucket(t *testing.T) {
+	limiters := NewKeyed(func(string) *TokenBucket { return NewTokenBucket(1, 0.5) }, KeyedOptions{})
+	handler := Middleware(limiters, MiddlewareOptions{KeyFunc: HeaderKey("X-API-Key")})(okHandler())
+
+	var rec *httptest.ResponseRecorder
+	for range 2 {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("X-API-Key", "key")
+		rec = httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+	}
+
+	if rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") != "2" {
+		t.Errorf("expected 429 with Retry-After 2, got %d and %q", rec.Code, rec.Header().Get("Retry-After"))
+	}
+}
+
+func TestRemoteIP(t *testing.T) {
+	proxies := []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}
+
+	tests := []struct {
+		name       string
+		proxies    []netip.Prefix
+		remoteAddr string
+		forwarded  []string
+		want       string
+	}{
+		{"no proxies", nil, "192.0.2.1:1234", []string{"198.51.100.1"}, "192.0.2.1"},
+		{"untrusted remote", proxies, "192.0.2.1:1234", []string{"198.51.100.1"}, "192.0.2.1"},
+		{"trusted proxy", proxies, "10.0.0.1:1234", []string{"198.51.100.1"}, "198.51.100.1"},
+		{"forged entries", proxies, "10.0.0.1:1234", []string{"203.0.113.9, 198.51.100.1, 10.0.0.2"}, "198.51.100.1"},
+		{"multiple headers", proxies, "10.0.0.1:1234", []string{"198.51.100.1", "10.0.0.2"}, "198.51.100.1"},
+		{"invalid entry", proxies, "10.0.0.1:1234", []string{"198.51.100.1, garbage"}, "10.0.0.1"},
+		{"only proxies", proxies, "10.0.0.1:1234", []string{"10.0.0.3, 10.0.0.2"}, "10.0.0.3"},
+		{"ipv6", nil, "[2001:db8::1]:1234", nil, "2001:db8::1"},
+		{"no port", nil, "192.0.2.1", nil, "192.0.2.1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			for _, v := range tt.forwarded {
+				req.Header.Add("X-Forwarded-For", v)
+			}
+
+			if got := RemoteIP(tt.proxies...)(req); got != tt.want {
+				t.Errorf("RemoteIP() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+type subjectKey struct{}
+
+func TestContextKey(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if got := ContextKey(subjectKey{})(req); got != "" {
+		t.Errorf("expected empty key without a value, got %q", got)
+	}
+
+	req = req.WithContext(context.WithValue(req.Context(), subjectKey{}, "user-1"))
+	if got := ContextKey(subjectKey{})(req); got != "user-1" {
+		t.Errorf("ContextKey() = %q, want user-1", got)
+	}
+
+	req = req.WithContext(context.WithValue(req.Context(), subjectKey{}, 42))
+	if got := ContextKey(subjectKey{})(req); got != "42" {
+		t.Errorf("ContextKey() = %q, want 42", got)
+	}
+}
+
+func TestStatus(t *testing.T) {
+	t.Run("token bucket", func(t *testing.T) {
+		tb := NewTokenBucket(4, 2)
+		tb.AllowN(4)
+		tb.tokens = 0.5
+
+		st := tb.Status()
+		if st.Limit != 4 || st.Remaining != 0 {
+			t.Errorf("unexpected status %+v", st)
+		}
+		if st.Reset < 1700*time.Millisecond || st.Reset > 1750*time.Millisecond {
+			t.Errorf("expected a reset of about 1.75s, got %v", st.Reset)
+		}
+		if st.RetryAfter <= 0 || st.RetryAfter > 250*time.Millisecond {
+			t.Errorf("expected a retry after of about 250ms, got %v", st.RetryAfter)
+		}
+	})
+
+	t.Run("fixed window", func(t *testing.T) {
+		fw := NewFixedWindow(2, time.Minute)
+		if st := fw.Status(); st != (Status{Limit: 2, Remaining: 2}) {
+			t.Errorf("unexpected status before any event %+v", st)
+		}
+		fw.Allow()
+		fw.Allow()
+		if st := fw.Status(); st.Remaining != 0 || st.RetryAfter != st.Reset || st.Reset <= 59*time.Second {
+			t.Errorf("unexpected status %+v", st)
+		}
+	})
+
+	t.Run("sliding window log", func(t *testing.T) {
+		l := NewSlidingWindowLog(2, time.Minute)
+		now := time.Now()
+		l.events = []windowEvent{{at: now.Add(-50 * time.Second), n: 1}, {at: now.Add(-10 * time.Second), n: 1}}
+		l.count = 2
+
+		st := l.Status()
+		if st.Remaining != 0 || st.RetryAfter > 10*time.Second || st.Reset > 50*time.Second || st.Reset < 49*time.Second {
+			t.Errorf("unexpected status %+v", st)
+		}
+	})
+
+	t.Run("sliding window counter", func(t *testing.T) {
+		l := NewSlidingWindowCounter(10, time.Minute)
+		l.windowStart = time.Now().Add(-30 * time.Second)
+		l.prevCount = 10
+		l.currCount = 4
+
+		st := l.Status()
+		// 10 * 0.5 + 4 = 9 events in the sliding window
+		if st.Remaining != 1 || st.RetryAfter != 0 || st.Reset > 90*time.Second || st.Reset < 89*time.Second {
+			t.Errorf("unexpected status %+v", st)
+		}
+	})
+}
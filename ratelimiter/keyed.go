@@ -0,0 +1,223 @@
+package ratelimiter
+
+import (
+	"container/list"
+	"hash/maphash"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/kashifkhan0771/utils/clock"
+)
+
+// DefaultKeyedShards is the number of shards a Keyed limiter uses when
+// KeyedOptions.Shards is not set.
+const DefaultKeyedShards = 32
+
+// Limiter is the non-blocking check shared by all limiters in this package.
+type Limiter interface {
+	Allow() bool
+}
+
+// Status is the state of a limiter, as reported in rate-limit response headers.
+type Status struct {
+	Limit      int           // Maximum number of events, e.g. the capacity or the events per window
+	Remaining  int           // Number of events that would be allowed right now
+	Reset      time.Duration // Time until Remaining is back at Limit
+	RetryAfter time.Duration // Time until the next event is allowed, 0 if Remaining > 0
+}
+
+// StatusReporter is implemented by limiters that can report their Status:
+// TokenBucket, FixedWindow, SlidingWindowLog, SlidingWindowCounter and GCRA.
+// ConcurrencyLimiter and AdaptiveLimiter limit operations in flight rather
+// than a rate and do not implement it.
+type StatusReporter interface {
+	Status() Status
+}
+
+// KeyedOptions configures a Keyed limiter.
+type KeyedOptions struct {
+	IdleTTL time.Duration // Keys unused for this long are evicted. 0 means keys never expire
+	MaxKeys int           // Maximum number of keys kept, least recently used are evicted first. 0 means no limit
+	Shards  int           // Number of independently locked shards. Defaults to DefaultKeyedShards
+	Clock   clock.Clock   // Time source for idle tracking. Defaults to the real clock
+}
+
+// KeyStats reports the activity of a single key of a Keyed limiter.
+type KeyStats struct {
+	Allowed    uint64    // Number of calls to Allow that were allowed
+	Denied     uint64    // Number of calls to Allow that were denied
+	LastSeen   time.Time // Last time the key was used
+	Overridden bool      // Whether the limiter was set with Override
+}
+
+// Keyed maintains a separate limiter per key, such as per API key or client IP.
+// Limiters are created lazily by a factory on first use and evicted once idle
+// for longer than IdleTTL, or when there are more than MaxKeys keys.
+// Keys are spread over shards with their own lock, so it is safe for heavily
+// concurrent use.
+type Keyed[K comparable, L Limiter] struct {
+	newLimiter func(key K) L
+	idleTTL    time.Duration
+	seed       maphash.Seed
+	shards     []*keyedShard[K, L]
+	clock      clock.Clock
+}
+
+// keyedShard holds the keys that hash to one shard.
+type keyedShard[K comparable, L Limiter] struct {
+	mu      sync.Mutex
+	entries map[K]*keyedEntry[K, L]
+	lru     *list.List // Entries not overridden, most recently used first
+	maxKeys int
+}
+
+// keyedEntry is the limiter of a single key.
+type keyedEntry[K comparable, L Limiter] struct {
+	key      K
+	limiter  L
+	lastSeen time.Time
+	elem     *list.Element // Position in the shard's LRU list, nil if overridden
+	allowed  atomic.Uint64
+	denied   atomic.Uint64
+}
+
+// NewKeyed creates a Keyed limiter that calls newLimiter to create the limiter
+// of a key the first time it is used, e.g.
+//
+//	func(string) *TokenBucket { return NewTokenBucket(10, 1) }
+//
+// newLimiter must not be nil. It runs while the key's shard is locked, so it should be fast.
+// MaxKeys is split evenly between shards, rounding down, so that no more than
+// MaxKeys keys are ever kept; fewer may be when MaxKeys is not a multiple of
+// the number of shards or keys are unevenly spread. The number of shards is
+// reduced to MaxKeys if it is smaller, so each shard keeps at least one key.
+func NewKeyed[K comparable, L Limiter](newLimiter func(key K) L, opts KeyedOptions) *Keyed[K, L] {
+	shards := opts.Shards
+	if shards < 1 {
+		shards = DefaultKeyedShards
+	}
+	if opts.MaxKeys > 0 && opts.MaxKeys < shards {
+		shards = opts.MaxKeys
+	}
+
+	maxKeys := 0
+	if opts.MaxKeys > 0 {
+		maxKeys = opts.MaxKeys / shards
+	}
+
+	k := &Keyed[K, L]{
+		newLimiter: newLimiter,
+		idleTTL:    max(opts.IdleTTL, 0),
+		seed:       maphash.MakeSeed(),
+		shards:     make([]*keyedShard[K, L], shards),
+		clock:      clock.OrDefault(opts.Clock),
+	}
+	for i := range k.shards {
+		k.shards[i] = &keyedShard[K, L]{
+			entries: make(map[K]*keyedEntry[K, L]),
+			lru:     list.New(),
+			maxKeys: maxKeys,
+		}
+	}
+
+	return k
+}
+
+// Allow reports whether an event for key is allowed by the key's limiter,
+// creating the limiter if needed, and records the result in the key's stats.
+func (k *Keyed[K, L]) Allow(key K) bool {
+	_, ok := k.allow(key)
+
+	return ok
+}
+
+// Get returns the limiter of key, creating it if needed, so that methods
+// beyond Allow, such as Wait, can be used. Calls through the returned limiter
+// are not counted in the key's stats.
+func (k *Keyed[K, L]) Get(key K) L {
+	_, limiter := k.shard(key).get(key, k.clock.Now(), k.idleTTL, k.newLimiter)
+
+	return limiter
+}
+
+// Override sets the limiter used for key, replacing the one created by the factory.
+// Overridden keys are never evicted and do not count towards MaxKeys, until removed with Delete.
+func (k *Keyed[K, L]) Override(key K, limiter L) {
+	s := k.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[key]
+	if !ok {
+		entry = &keyedEntry[K, L]{key: key}
+		s.entries[key] = entry
+	}
+	if entry.elem != nil {
+		s.lru.Remove(entry.elem)
+		entry.elem = nil
+	}
+	entry.limiter = limiter
+	entry.lastSeen = k.clock.Now()
+}
+
+// Delete removes key and its stats. The next use of key creates a new limiter with the factory.
+func (k *Keyed[K, L]) Delete(key K) {
+	s := k.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if entry, ok := s.entries[key]; ok {
+		s.remove(entry)
+	}
+}
+
+// Stats returns the stats of key, or false if the key is not tracked.
+func (k *Keyed[K, L]) Stats(key K) (KeyStats, bool) {
+	s := k.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[key]
+	if !ok || s.expired(entry, k.clock.Now(), k.idleTTL) {
+		return KeyStats{}, false
+	}
+
+	return KeyStats{
+		Allowed:    entry.allowed.Load(),
+		Denied:     entry.denied.Load(),
+		LastSeen:   entry.lastSeen,
+		Overridden: entry.elem == nil,
+	}, true
+}
+
+// Len returns the number of tracked keys, including idle keys not evicted yet.
+func (k *Keyed[K, L]) Len() int {
+	n := 0
+	for _, s := range k.shards {
+		s.mu.Lock()
+		n += len(s.entries)
+		s.mu.Unlock()
+	}
+
+	return n
+}
+
+// Prune evicts every key idle for longer than IdleTTL and returns how many were evicted.
+// Idle keys are also evicted as new keys are added; call Prune periodically to
+// release memory when few new keys arrive.
+func (k *Keyed[K, L]) Prune() int {
+	if k.idleTTL <= 0 {
+		return 0
+	}
+
+	now := k.clock.Now()
+	n := 0
+	for _, s := range k.shards {
+		s.mu.Lock()
+		n += s.evictIdle(now, k.idleTTL)
+		s.mu.Unlock()
+	}
+
+	return n
+}
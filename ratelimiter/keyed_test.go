@@ -0,0 +1,202 @@
+package ratelimiter
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kashifkhan0771/utils/clock"
+)
+
+// newTestKeyed creates a Keyed limiter of 1 event per minute per key with a fake clock.
+func newTestKeyed(opts KeyedOptions) (*Keyed[string, *FixedWindow], *clock.Fake) {
+	clk := clock.NewFake(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	opts.Clock = clk
+	k := NewKeyed(func(string) *FixedWindow {
+		fw := NewFixedWindow(1, time.Minute)
+		fw.SetClock(clk)
+
+		return fw
+	}, opts)
+
+	return k, clk
+}
+
+func TestKeyed_SeparateLimitsPerKey(t *testing.T) {
+	k, _ := newTestKeyed(KeyedOptions{})
+
+	if !k.Allow("a") || !k.Allow("b") {
+		t.Fatal("expected the first event of each key to be allowed")
+	}
+	if k.Allow("a") {
+		t.Fatal("expected the second event of key a to be denied")
+	}
+
+	stats, ok := k.Stats("a")
+	if !ok || stats.Allowed != 1 || stats.Denied != 1 || stats.Overridden {
+		t.Errorf("unexpected stats for key a: %+v, %v", stats, ok)
+	}
+	if _, ok := k.Stats("c"); ok {
+		t.Error("expected no stats for an unknown key")
+	}
+	if k.Len() != 2 {
+		t.Errorf("expected 2 keys, got %d", k.Len())
+	}
+}
+
+func TestKeyed_IdleTTL(t *testing.T) {
+	k, clk := newTestKeyed(KeyedOptions{IdleTTL: time.Minute})
+
+	k.Allow("a")
+	clk.Advance(30 * time.Second)
+	k.Allow("b")
+
+	clk.Advance(45 * time.Second) // a is idle for 75s, b for 45s
+	if _, ok := k.Stats("a"); ok {
+		t.Error("expected key a to have expired")
+	}
+	if n := k.Prune(); n != 1 {
+		t.Errorf("expected Prune to evict 1 key, evicted %d", n)
+	}
+	if k.Len() != 1 {
+		t.Errorf("expected 1 key left, got %d", k.Len())
+	}
+
+	// Using an expired key starts over with a fresh limiter
+	clk.Advance(2 * time.Minute)
+	if !k.Allow("b") {
+		t.Error("expected a fresh limiter for an expired key")
+	}
+	if stats, _ := k.Stats("b"); stats.Allowed != 1 || stats.Denied != 0 {
+		t.Errorf("expected stats to be reset, got %+v", stats)
+	}
+}
+
+func TestKeyed_MaxKeysEvictsLeastRecentlyUsed(t *testing.T) {
+	k, clk := newTestKeyed(KeyedOptions{MaxKeys: 2, Shards: 1})
+
+	k.Allow("a")
+	clk.Advance(time.Second)
+	k.Allow("b")
+	clk.Advance(time.Second)
+	k.Allow("a") // a is now the most recently used
+	clk.Advance(time.Second)
+	k.Allow("c")
+
+	if _, ok := k.Stats("b"); ok {
+		t.Error("expected key b to be evicted")
+	}
+	if _, ok := k.Stats("a"); !ok {
+		t.Error("expected key a to be kept")
+	}
+	if k.Len() != 2 {
+		t.Errorf("expected 2 keys, got %d", k.Len())
+	}
+}
+
+func TestKeyed_Override(t *testing.T) {
+	k, clk := newTestKeyed(KeyedOptions{IdleTTL: time.Minute, MaxKeys: 1, Shards: 1})
+
+	k.Allow("vip")
+	k.Override("vip", NewFixedWindow(3, time.Minute))
+	for i := range 3 {
+		if !k.Allow("vip") {
+			t.Fatalf("expected event %d to be allowed by the override", i+1)
+		}
+	}
+
+	// Overridden keys are neither evicted by MaxKeys nor by IdleTTL
+	k.Allow("other")
+	clk.Advance(time.Hour)
+	k.Prune()
+
+	stats, ok := k.Stats("vip")
+	if !ok || !stats.Overridden || stats.Allowed != 4 {
+		t.Errorf("unexpected stats for overridden key: %+v, %v", stats, ok)
+	}
+
+	k.Delete("vip")
+	if _, ok := k.Stats("vip"); ok {
+		t.Error("expected Delete to remove the override")
+	}
+	if !k.Allow("vip") || k.Allow("vip") {
+		t.Error("expected the factory limiter after Delete")
+	}
+}
+
+func TestKeyed_Get(t *testing.T) {
+	k := NewKeyed(func(string) *TokenBucket { return NewTokenBucket(2, 1) }, KeyedOptions{})
+
+	tb := k.Get("a")
+	if k.Get("a") != tb {
+		t.Fatal("expected Get to return the same limiter for a key")
+	}
+	tb.AllowN(2)
+	if k.Allow("a") {
+		t.Error("expected Allow to use the limiter returned by Get")
+	}
+}
+
+func TestNewKeyed_Shards(t *testing.T) {
+	tests := []struct {
+		opts            KeyedOptions
+		shards          int
+		maxKeysPerShard int
+	}{
+		{KeyedOptions{}, DefaultKeyedShards, 0},
+		{KeyedOptions{Shards: 4, MaxKeys: 10}, 4, 2},
+		{KeyedOptions{Shards: 8, MaxKeys: 2}, 2, 1},
+	}
+	for _, tt := range tests {
+		k := NewKeyed(func(int) *FixedWindow { return NewFixedWindow(1, time.Second) }, tt.opts)
+		if len(k.shards) != tt.shards || k.shards[0].maxKeys != tt.maxKeysPerShard {
+			t.Errorf("%+v: got %d shards of %d keys, want %d of %d",
+				tt.opts, len(k.shards), k.shards[0].maxKeys, tt.shards, tt.maxKeysPerShard)
+		}
+	}
+}
+
+func TestKeyed_MaxKeysUnevenSplit(t *testing.T) {
+	k := NewKeyed(func(int) *FixedWindow { return NewFixedWindow(1, time.Second) }, KeyedOptions{MaxKeys: 33})
+
+	for key := range 1000 {
+		k.Allow(key)
+		if n := k.Len(); n > 33 {
+			t.Fatalf("Len() = %d after %d keys, want at most MaxKeys (33)", n, key+1)
+		}
+	}
+}
+
+func TestKeyed_ConcurrentAccess(t *testing.T) {
+	k := NewKeyed(func(string) *FixedWindow { return NewFixedWindow(10, time.Minute) }, KeyedOptions{MaxKeys: 64})
+
+	var wg sync.WaitGroup
+	for g := range 16 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 200 {
+				key := fmt.Sprintf("key-%d", (g*i)%100)
+				k.Allow(key)
+				k.Stats(key)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if k.Len() > 64 {
+		t.Errorf("expected at most 64 keys, got %d", k.Len())
+	}
+}
+
+func BenchmarkKeyed_Allow(b *testing.B) {
+	k := NewKeyed(func(int) *TokenBucket { return NewTokenBucket(100, 100) }, KeyedOptions{MaxKeys: 1000})
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			k.Allow(i % 2000)
+			i++
+		}
+	})
+}
@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"sync"
 	"time"
+
+	"github.com/kashifkhan0771/utils/clock"
 )
 
 // TokenBucket implements a thread-safe token bucket rate limiter that allows
@@ -15,6 +17,7 @@ type TokenBucket struct {
 	tokens     float64
 	refillRate float64
 	last       time.Time
+	clock      clock.Clock
 }
 
 // NewTokenBucket creates a new TokenBucket with the specified capacity and refill rate.
@@ -32,11 +35,14 @@ func NewTokenBucket(capacity int, refillRate float64) *TokenBucket {
 		refillRate = 1
 	}
 
+	c := clock.New()
+
 	return &TokenBucket{
 		capacity:   capacity,
 		tokens:     float64(capacity),
 		refillRate: refillRate,
-		last:       time.Now(),
+		last:       c.Now(),
+		clock:      c,
 	}
 }
 
@@ -56,9 +62,10 @@ func (t *TokenBucket) AllowN(n int) bool {
 		return true
 	}
 
-	now := time.Now()
 	t.mu.Lock()
 	defer t.mu.Unlock()
+
+	now := t.clock.Now()
 	t.refill(now)
 
 	if t.tokens >= float64(n) {
@@ -87,8 +94,9 @@ func (t *TokenBucket) WaitN(ctx context.Context, n int) error {
 	}
 
 	for {
-		now := time.Now()
 		t.mu.Lock()
+		clk := t.clock
+		now := clk.Now()
 		if n > t.capacity {
 			currentCap := t.capacity
 			t.mu.Unlock()
@@ -119,7 +127,7 @@ func (t *TokenBucket) WaitN(ctx context.Context, n int) error {
 			select {
 			case <-ctx.Done():
 				return ctx.Err()
-			case <-time.After(1 * time.Millisecond):
+			case <-clk.After(1 * time.Millisecond):
 				// Yield CPU with a slightly longer sleep to prevent excessive busy-waiting
 				// while still being responsive to context cancellation
 			}
@@ -127,31 +135,89 @@ func (t *TokenBucket) WaitN(ctx context.Context, n int) error {
 			continue
 		}
 
-		timer := time.NewTimer(d)
+		timer := clk.NewTimer(d)
 		select {
 		case <-ctx.Done():
 			timer.Stop()
 
 			return ctx.Err()
-		case <-timer.C:
+		case <-timer.C():
 			// Timer expired, loop to check tokens again
 
 		}
 	}
 }
 
+// Reserve reserves one token and returns a Reservation telling how long to wait before using it.
+// This is a convenience method that calls ReserveN(1).
+func (t *TokenBucket) Reserve() *Reservation {
+	return t.ReserveN(1)
+}
+
+// ReserveN reserves n tokens and returns a Reservation telling how long to wait before using them.
+// Unlike WaitN it never blocks: the tokens are taken right away, even if that leaves the bucket
+// in debt, and later callers wait until the debt has been refilled.
+// The Reservation is not OK if n exceeds the bucket capacity, in which case nothing is reserved.
+// Returns an OK reservation with no delay if n is less or equal to 0.
+func (t *TokenBucket) ReserveN(n int) *Reservation {
+	if n <= 0 {
+		return &Reservation{ok: true}
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.clock.Now()
+	if n > t.capacity {
+		return &Reservation{}
+	}
+	t.refill(now)
+
+	timeToAct := now.Add(t.nextAvailableDuration(n))
+	t.tokens -= float64(n)
+
+	return &Reservation{
+		ok:        true,
+		timeToAct: timeToAct,
+		clock:     t.clock,
+		cancel:    func() { t.refund(n, timeToAct) },
+	}
+}
+
 // Tokens returns the current number of available tokens as a float64.
+// The result is negative while reservations are waiting for tokens.
 // This method is thread-safe and does not consume any tokens.
 // The returned value is approximate and may change immediately after the call returns.
 func (t *TokenBucket) Tokens() float64 {
-	now := time.Now()
 	t.mu.Lock()
 	defer t.mu.Unlock()
+
+	now := t.clock.Now()
 	t.refill(now)
 
 	return t.tokens
 }
 
+// Status reports the capacity, the whole tokens available, how long until the
+// bucket is full again and how long until the next token is available.
+// This method is thread-safe and does not consume any tokens.
+func (t *TokenBucket) Status() Status {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.clock.Now()
+	t.refill(now)
+
+	missing := float64(t.capacity) - t.tokens
+
+	return Status{
+		Limit:      t.capacity,
+		Remaining:  max(int(t.tokens), 0),
+		Reset:      time.Duration(missing / t.refillRate * float64(time.Second)),
+		RetryAfter: t.nextAvailableDuration(1),
+	}
+}
+
 // SetCapacity adjusts the bucket capacity at runtime.
 // If the new capacity is smaller than the current number of tokens,
 // the token count is reduced to match the new capacity.
@@ -162,9 +228,10 @@ func (t *TokenBucket) SetCapacity(cap int) {
 		cap = 1
 	}
 
-	now := time.Now()
 	t.mu.Lock()
 	defer t.mu.Unlock()
+
+	now := t.clock.Now()
 	t.refill(now)
 	t.capacity = cap
 
@@ -181,13 +248,26 @@ func (t *TokenBucket) SetRefillRate(rate float64) {
 		return
 	}
 
-	now := time.Now()
 	t.mu.Lock()
 	defer t.mu.Unlock()
+
+	now := t.clock.Now()
 	t.refill(now)
 	t.refillRate = rate
 }
 
+// SetClock replaces the time source of the bucket, e.g. with a fake clock in tests.
+// Refilling continues from the current time of c. A nil c restores the real clock.
+// This method is thread-safe.
+func (t *TokenBucket) SetClock(c clock.Clock) {
+	c = clock.OrDefault(c)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.clock = c
+	t.last = c.Now()
+}
+
 // FixedWindow implements a fixed window rate limiter.
 // It allows up to 'limit' events per 'interval' duration.
 // The window resets after each interval.
@@ -197,6 +277,8 @@ type FixedWindow struct {
 	mu            sync.Mutex    // Mutex to protect concurrent access
 	limit         int           // Maximum allowed events per window
 	count         int           // Current count of events in the window
+	ahead         []int         // Counts of events reserved in the windows after the current one
+	clock         clock.Clock   // Time source
 }
 
 // NewFixedWindow creates a new FixedWindow rate limiter with the given limit and interval.
@@ -209,28 +291,38 @@ func NewFixedWindow(limit int, interval time.Duration) *FixedWindow {
 		interval = 1 * time.Second
 	}
 
+	c := clock.New()
+
 	return &FixedWindow{
 		limit:         limit,
 		interval:      interval,
-		windowEndTime: time.Now().Add(interval),
+		windowEndTime: c.Now().Add(interval),
+		clock:         c,
 	}
 }
 
 // Allow checks if a new event is allowed under the rate limit.
 // Returns true if allowed, false otherwise.
+// This is a convenience method that calls AllowN(1).
 func (l *FixedWindow) Allow() bool {
+	return l.AllowN(1)
+}
+
+// AllowN checks if n more events fit in the current window and records them atomically.
+// Returns true if allowed, false otherwise. Returns true if n is less or equal to 0.
+func (l *FixedWindow) AllowN(n int) bool {
+	if n <= 0 {
+		return true
+	}
+
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
-	now := time.Now()
-
-	if now.After(l.windowEndTime) {
-		l.count = 0
-		l.windowEndTime = now.Add(l.interval)
-	}
+	now := l.clock.Now()
+	l.advance(now)
 
-	if l.count < l.limit {
-		l.count++
+	if l.count+n <= l.limit {
+		l.count += n
 
 		return true
 	}
@@ -238,23 +330,148 @@ func (l *FixedWindow) Allow() bool {
 	return false
 }
 
+// Wait blocks until a new event is allowed and records it, or until the context is cancelled.
+// This is a convenience method that calls WaitN(ctx, 1).
+func (l *FixedWindow) Wait(ctx context.Context) error {
+	return l.WaitN(ctx, 1)
+}
+
+// WaitN blocks until the first window with room for n events starts, or until the context is cancelled.
+// The events are reserved as soon as WaitN is called, so waiters are served in order,
+// and are given back if the context is cancelled first.
+// Returns an error if n exceeds the limit, or if the context is cancelled.
+func (l *FixedWindow) WaitN(ctx context.Context, n int) error {
+	if n <= 0 {
+		return nil
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r := l.ReserveN(n)
+	if !r.OK() {
+		l.mu.Lock()
+		limit := l.limit
+		l.mu.Unlock()
+
+		return fmt.Errorf("requested events %d exceeds limit %d", n, limit)
+	}
+
+	d := r.Delay()
+	if d == 0 {
+		return nil
+	}
+
+	timer := r.clock.NewTimer(d)
+	select {
+	case <-ctx.Done():
+		timer.Stop()
+		r.Cancel()
+
+		return ctx.Err()
+	case <-timer.C():
+		return nil
+	}
+}
+
+// Reserve reserves a new event and returns a Reservation telling how long to wait before it may happen.
+// This is a convenience method that calls ReserveN(1).
+func (l *FixedWindow) Reserve() *Reservation {
+	return l.ReserveN(1)
+}
+
+// ReserveN reserves n events in the first window with room for them, which may be
+// a later window, and returns a Reservation telling how long to wait for that window.
+// The Reservation is not OK if n exceeds the limit, in which case nothing is reserved.
+// Changing the limit or interval does not move existing reservations.
+// Returns an OK reservation with no delay if n is less or equal to 0.
+func (l *FixedWindow) ReserveN(n int) *Reservation {
+	if n <= 0 {
+		return &Reservation{ok: true}
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if n > l.limit {
+		return &Reservation{}
+	}
+
+	now := l.clock.Now()
+	l.advance(now)
+
+	// Find the first window with room, -1 being the current one
+	window, windowEnd, timeToAct := -1, l.windowEndTime, now
+	count := l.count
+	for count+n > l.limit {
+		window++
+		if window == len(l.ahead) {
+			l.ahead = append(l.ahead, 0)
+		}
+		count = l.ahead[window]
+		timeToAct = windowEnd
+		windowEnd = windowEnd.Add(l.interval)
+	}
+
+	if window < 0 {
+		l.count += n
+	} else {
+		l.ahead[window] += n
+	}
+
+	return &Reservation{
+		ok:        true,
+		timeToAct: timeToAct,
+		clock:     l.clock,
+		cancel:    func() { l.refund(n, windowEnd, timeToAct) },
+	}
+}
+
+// Status reports the limit, the events left in the current window and how long until
+// the window resets, or until the last window with reserved events ends.
+// This method is thread-safe and does not record any event.
+func (l *FixedWindow) Status() Status {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.clock.Now()
+	l.advance(now)
+
+	if l.count == 0 && len(l.ahead) == 0 {
+		return Status{Limit: l.limit, Remaining: l.limit}
+	}
+
+	untilEnd := l.windowEndTime.Sub(now)
+	status := Status{
+		Limit:     l.limit,
+		Remaining: max(l.limit-l.count, 0),
+		Reset:     untilEnd + time.Duration(len(l.ahead))*l.interval,
+	}
+	if status.Remaining == 0 {
+		// Wait for the first window with room
+		status.RetryAfter = untilEnd
+		for i := 0; i < len(l.ahead) && l.ahead[i] >= l.limit; i++ {
+			status.RetryAfter += l.interval
+		}
+	}
+
+	return status
+}
+
 // SetInterval updates the interval duration for the rate limiter.
 // If interval <= 0, it defaults to 1 second.
 func (l *FixedWindow) SetInterval(interval time.Duration) {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
-	now := time.Now()
+	now := l.clock.Now()
 
 	if interval <= 0 {
 		interval = 1 * time.Second
 	}
 	l.interval = interval
 
-	if now.After(l.windowEndTime) {
-		l.count = 0
-		l.windowEndTime = now.Add(l.interval)
-	}
+	l.advance(now)
 }
 
 // SetLimit updates the maximum allowed events per window.
@@ -263,7 +480,7 @@ func (l *FixedWindow) SetLimit(limit int) {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
-	now := time.Now()
+	now := l.clock.Now()
 
 	if limit <= 0 {
 		limit = 1
@@ -271,8 +488,18 @@ func (l *FixedWindow) SetLimit(limit int) {
 
 	l.limit = limit
 
-	if now.After(l.windowEndTime) {
-		l.count = 0
-		l.windowEndTime = now.Add(l.interval)
-	}
+	l.advance(now)
+}
+
+// SetClock replaces the time source of the rate limiter, e.g. with a fake clock in tests.
+// The current window restarts at the current time of c, keeping its count.
+// A nil c restores the real clock.
+func (l *FixedWindow) SetClock(c clock.Clock) {
+	c = clock.OrDefault(c)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.clock = c
+	l.windowEndTime = c.Now().Add(l.interval)
 }
@@ -0,0 +1,52 @@
+package ratelimiter
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/kashifkhan0771/utils/clock"
+)
+
+// InfDuration is the delay of a Reservation that is not OK.
+const InfDuration = time.Duration(math.MaxInt64)
+
+// Reservation holds events reserved from a TokenBucket, FixedWindow or GCRA, which
+// may only happen after a delay. The caller decides whether to wait for them
+// or give them back with Cancel.
+type Reservation struct {
+	ok        bool
+	timeToAct time.Time   // When the reserved events may happen
+	clock     clock.Clock // Time source of the limiter, nil if nothing had to be reserved
+	cancel    func()      // Gives the reserved events back to the limiter, nil if there is nothing to give back
+	once      sync.Once
+}
+
+// OK reports whether the events could be reserved. A limiter can't reserve
+// more events than its capacity, limit or burst.
+func (r *Reservation) OK() bool {
+	return r.ok
+}
+
+// Delay returns how long to wait from now before the reserved events may happen.
+// Returns 0 if they may happen right away, and InfDuration if the reservation is not OK.
+func (r *Reservation) Delay() time.Duration {
+	if !r.ok {
+		return InfDuration
+	}
+	if r.clock == nil {
+		return 0
+	}
+
+	return max(r.timeToAct.Sub(r.clock.Now()), 0)
+}
+
+// Cancel gives the reserved events back to the limiter, so other callers can use them.
+// It has no effect once the delay has passed, and when called more than once.
+func (r *Reservation) Cancel() {
+	r.once.Do(func() {
+		if r.ok && r.cancel != nil {
+			r.cancel()
+		}
+	})
+}
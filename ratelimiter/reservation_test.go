@@ -0,0 +1,199 @@
+package ratelimiter
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTokenBucket_ReserveN(t *testing.T) {
+	tb := NewTokenBucket(2, 10) // a token every 100ms
+
+	r := tb.ReserveN(2)
+	if !r.OK() || r.Delay() != 0 {
+		t.Fatalf("expected an immediate reservation, got ok=%v delay=%v", r.OK(), r.Delay())
+	}
+
+	r = tb.Reserve()
+	if !r.OK() {
+		t.Fatal("expected the reservation to be OK")
+	}
+	if d := r.Delay(); d < 80*time.Millisecond || d > 100*time.Millisecond {
+		t.Errorf("expected a delay of about 100ms, got %v", d)
+	}
+
+	// The next reservation queues behind the previous one
+	if d := tb.Reserve().Delay(); d < 180*time.Millisecond || d > 200*time.Millisecond {
+		t.Errorf("expected a delay of about 200ms, got %v", d)
+	}
+	if tb.Allow() {
+		t.Error("expected Allow to be denied while the bucket is in debt")
+	}
+	if tb.Status().Remaining != 0 {
+		t.Errorf("expected 0 remaining tokens, got %d", tb.Status().Remaining)
+	}
+}
+
+func TestTokenBucket_ReserveNEdgeCases(t *testing.T) {
+	tb := NewTokenBucket(2, 1)
+
+	if r := tb.ReserveN(3); r.OK() || r.Delay() != InfDuration {
+		t.Errorf("expected a reservation over capacity to fail, got ok=%v delay=%v", r.OK(), r.Delay())
+	}
+	if r := tb.ReserveN(0); !r.OK() || r.Delay() != 0 {
+		t.Errorf("expected ReserveN(0) to be OK without delay")
+	}
+	if tb.Tokens() < 1.99 {
+		t.Errorf("expected no tokens to be taken, got %v", tb.Tokens())
+	}
+	tb.ReserveN(3).Cancel() // Cancelling a failed reservation is a no-op
+}
+
+func TestTokenBucket_ReservationCancel(t *testing.T) {
+	tb := NewTokenBucket(1, 1)
+	tb.Allow()
+
+	r := tb.Reserve()
+	if tb.Tokens() > -0.9 {
+		t.Fatalf("expected the bucket to be in debt, got %v", tb.Tokens())
+	}
+
+	r.Cancel()
+	r.Cancel() // A second cancel must not refund twice
+	if tokens := tb.Tokens(); tokens < 0 || tokens > 0.1 {
+		t.Errorf("expected the token to be refunded, got %v", tokens)
+	}
+
+	// Tokens that were due already can't be refunded
+	tb2 := NewTokenBucket(1, 1)
+	immediate := tb2.Reserve()
+	immediate.Cancel()
+	if tb2.Tokens() > 0.1 {
+		t.Errorf("expected no refund for an immediate reservation, got %v", tb2.Tokens())
+	}
+}
+
+func TestFixedWindow_AllowN(t *testing.T) {
+	fw := NewFixedWindow(3, time.Minute)
+
+	tests := []struct {
+		n    int
+		want bool
+	}{
+		{2, true},
+		{2, false},
+		{1, true},
+		{0, true},
+		{-1, true},
+		{1, false},
+	}
+	for i, tt := range tests {
+		if got := fw.AllowN(tt.n); got != tt.want {
+			t.Errorf("call %d: AllowN(%d) = %v; want %v", i, tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestFixedWindow_ReserveN(t *testing.T) {
+	fw := NewFixedWindow(2, time.Minute)
+	fw.windowEndTime = time.Now().Add(30 * time.Second)
+
+	if r := fw.ReserveN(2); !r.OK() || r.Delay() != 0 {
+		t.Fatalf("expected an immediate reservation, got ok=%v delay=%v", r.OK(), r.Delay())
+	}
+
+	// The current window is full; the next one starts in 30s
+	next := fw.Reserve()
+	if d := next.Delay(); d < 29*time.Second || d > 30*time.Second {
+		t.Errorf("expected a delay of about 30s, got %v", d)
+	}
+	// Two events don't fit in the rest of the next window
+	later := fw.ReserveN(2)
+	if d := later.Delay(); d < 89*time.Second || d > 90*time.Second {
+		t.Errorf("expected a delay of about 90s, got %v", d)
+	}
+	if len(fw.ahead) != 2 || fw.ahead[0] != 1 || fw.ahead[1] != 2 {
+		t.Errorf("unexpected reserved windows %v", fw.ahead)
+	}
+
+	st := fw.Status()
+	if st.Remaining != 0 || st.RetryAfter > 30*time.Second || st.Reset < 149*time.Second {
+		t.Errorf("unexpected status %+v", st)
+	}
+
+	if r := fw.ReserveN(3); r.OK() {
+		t.Error("expected a reservation over the limit to fail")
+	}
+
+	later.Cancel()
+	if len(fw.ahead) != 1 {
+		t.Errorf("expected the cancelled window to be dropped, got %v", fw.ahead)
+	}
+	next.Cancel()
+	if len(fw.ahead) != 0 {
+		t.Errorf("expected no reserved windows left, got %v", fw.ahead)
+	}
+}
+
+func TestFixedWindow_ReservedWindowsFollowEachOther(t *testing.T) {
+	fw := NewFixedWindow(1, 20*time.Millisecond)
+	fw.Allow()
+	fw.Reserve()
+
+	// The reserved window follows the first one
+	time.Sleep(30 * time.Millisecond)
+	if fw.Allow() {
+		t.Fatal("expected the reserved window to be full")
+	}
+
+	// The reserved window ended too; a new one starts now
+	time.Sleep(20 * time.Millisecond)
+	if !fw.Allow() {
+		t.Fatal("expected an event after the reserved window ended")
+	}
+	if fw.Allow() {
+		t.Fatal("expected the new window to be full")
+	}
+}
+
+func TestFixedWindow_WaitN(t *testing.T) {
+	fw := NewFixedWindow(1, 50*time.Millisecond)
+
+	start := time.Now()
+	for range 2 {
+		if err := fw.Wait(context.Background()); err != nil {
+			t.Fatalf("Wait returned error: %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 40*time.Millisecond {
+		t.Errorf("expected the second event to wait for the next window, waited %v", elapsed)
+	}
+
+	if err := fw.WaitN(context.Background(), 2); err == nil {
+		t.Error("expected an error when waiting for more events than the limit")
+	}
+	if err := fw.WaitN(context.Background(), 0); err != nil {
+		t.Errorf("expected WaitN(0) to return nil, got %v", err)
+	}
+}
+
+func TestFixedWindow_WaitContextCancel(t *testing.T) {
+	fw := NewFixedWindow(1, time.Minute)
+	fw.Allow()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	if err := fw.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected DeadlineExceeded, got %v", err)
+	}
+	if len(fw.ahead) != 0 {
+		t.Errorf("expected the reservation to be given back, got %v", fw.ahead)
+	}
+
+	cancel()
+	if err := fw.Wait(ctx); err == nil {
+		t.Error("expected an error for a cancelled context")
+	}
+}
@@ -0,0 +1,312 @@
+package ratelimiter
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/kashifkhan0771/utils/clock"
+)
+
+// SlidingWindowLog implements an exact sliding window rate limiter.
+// It allows up to 'limit' events in any 'interval' long period by keeping
+// the time of every event still inside the window, so unlike FixedWindow it
+// never lets 2x bursts through at window boundaries.
+// Memory use grows with the limit.
+type SlidingWindowLog struct {
+	mu       sync.Mutex    // Mutex to protect concurrent access
+	limit    int           // Maximum allowed events per window
+	interval time.Duration // Length of the sliding window
+	events   []windowEvent // Events inside the window, oldest first
+	count    int           // Total number of events in events
+	clock    clock.Clock   // Time source
+}
+
+// windowEvent records n events allowed at the same time.
+type windowEvent struct {
+	at time.Time
+	n  int
+}
+
+// NewSlidingWindowLog creates a new SlidingWindowLog rate limiter with the given limit and interval.
+// If limit < 1, it defaults to 1. If interval <= 0, it defaults to 1 second.
+func NewSlidingWindowLog(limit int, interval time.Duration) *SlidingWindowLog {
+	if limit < 1 {
+		limit = 1
+	}
+	if interval <= 0 {
+		interval = 1 * time.Second
+	}
+
+	return &SlidingWindowLog{
+		limit:    limit,
+		interval: interval,
+		clock:    clock.New(),
+	}
+}
+
+// Allow checks if a new event is allowed under the rate limit and records it if so.
+// This is a convenience method that calls AllowN(1).
+func (l *SlidingWindowLog) Allow() bool {
+	return l.AllowN(1)
+}
+
+// AllowN checks if n events are allowed under the rate limit and records them atomically.
+// Returns true if n is less or equal to 0.
+// This method is thread-safe and non-blocking.
+func (l *SlidingWindowLog) AllowN(n int) bool {
+	if n <= 0 {
+		return true
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.clock.Now()
+
+	return l.allowN(now, n)
+}
+
+// Wait blocks until a new event is allowed and records it, or until the context is cancelled.
+// This is a convenience method that calls WaitN(ctx, 1).
+func (l *SlidingWindowLog) Wait(ctx context.Context) error {
+	return l.WaitN(ctx, 1)
+}
+
+// WaitN blocks until n events are allowed and records them, or until the context is cancelled.
+// Returns an error if n exceeds the limit, or if the context is cancelled first.
+func (l *SlidingWindowLog) WaitN(ctx context.Context, n int) error {
+	if n <= 0 {
+		return nil
+	}
+
+	return waitN(ctx, n, func() (bool, time.Duration, int, clock.Clock) {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+
+		now := l.clock.Now()
+		if l.allowN(now, n) {
+			return true, 0, l.limit, l.clock
+		}
+
+		return false, l.nextAvailableDuration(now, n), l.limit, l.clock
+	})
+}
+
+// Status reports the limit, the events that fit in the window right now and
+// how long until every recorded event has left the window.
+// This method is thread-safe and does not record any event.
+func (l *SlidingWindowLog) Status() Status {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.clock.Now()
+	l.prune(now)
+
+	status := Status{
+		Limit:     l.limit,
+		Remaining: max(l.limit-l.count, 0),
+	}
+	if len(l.events) > 0 {
+		status.Reset = l.events[len(l.events)-1].at.Add(l.interval).Sub(now)
+	}
+	if status.Remaining == 0 {
+		status.RetryAfter = l.nextAvailableDuration(now, 1)
+	}
+
+	return status
+}
+
+// SetLimit updates the maximum allowed events per window.
+// Events already recorded still count against the new limit.
+// If limit <= 0, it defaults to 1.
+func (l *SlidingWindowLog) SetLimit(limit int) {
+	if limit <= 0 {
+		limit = 1
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.limit = limit
+}
+
+// SetInterval updates the length of the sliding window.
+// If interval <= 0, it defaults to 1 second.
+func (l *SlidingWindowLog) SetInterval(interval time.Duration) {
+	if interval <= 0 {
+		interval = 1 * time.Second
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.interval = interval
+}
+
+// SetClock replaces the time source of the rate limiter, e.g. with a fake clock in tests.
+// Events recorded with the previous clock are forgotten. A nil c restores the real clock.
+func (l *SlidingWindowLog) SetClock(c clock.Clock) {
+	c = clock.OrDefault(c)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.clock = c
+	l.events = nil
+	l.count = 0
+}
+
+// SlidingWindowCounter implements an approximate sliding window rate limiter.
+// It counts events in fixed windows like FixedWindow, but weighs the count of
+// the previous window by how much of it still overlaps the sliding window.
+// This smooths out boundary bursts with constant memory, assuming events
+// were evenly spread over the previous window.
+type SlidingWindowCounter struct {
+	windowStart time.Time     // Start time of the current window
+	interval    time.Duration // Duration of each window
+	mu          sync.Mutex    // Mutex to protect concurrent access
+	limit       int           // Maximum allowed events per window
+	prevCount   int           // Count of events in the previous window
+	currCount   int           // Count of events in the current window
+	clock       clock.Clock   // Time source
+}
+
+// NewSlidingWindowCounter creates a new SlidingWindowCounter rate limiter with the given limit and interval.
+// If limit < 1, it defaults to 1. If interval <= 0, it defaults to 1 second.
+func NewSlidingWindowCounter(limit int, interval time.Duration) *SlidingWindowCounter {
+	if limit < 1 {
+		limit = 1
+	}
+	if interval <= 0 {
+		interval = 1 * time.Second
+	}
+
+	c := clock.New()
+
+	return &SlidingWindowCounter{
+		limit:       limit,
+		interval:    interval,
+		windowStart: c.Now(),
+		clock:       c,
+	}
+}
+
+// Allow checks if a new event is allowed under the rate limit and records it if so.
+// This is a convenience method that calls AllowN(1).
+func (l *SlidingWindowCounter) Allow() bool {
+	return l.AllowN(1)
+}
+
+// AllowN checks if n events are allowed under the rate limit and records them atomically.
+// Returns true if n is less or equal to 0.
+// This method is thread-safe and non-blocking.
+func (l *SlidingWindowCounter) AllowN(n int) bool {
+	if n <= 0 {
+		return true
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.clock.Now()
+
+	return l.allowN(now, n)
+}
+
+// Wait blocks until a new event is allowed and records it, or until the context is cancelled.
+// This is a convenience method that calls WaitN(ctx, 1).
+func (l *SlidingWindowCounter) Wait(ctx context.Context) error {
+	return l.WaitN(ctx, 1)
+}
+
+// WaitN blocks until n events are allowed and records them, or until the context is cancelled.
+// Returns an error if n exceeds the limit, or if the context is cancelled first.
+func (l *SlidingWindowCounter) WaitN(ctx context.Context, n int) error {
+	if n <= 0 {
+		return nil
+	}
+
+	return waitN(ctx, n, func() (bool, time.Duration, int, clock.Clock) {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+
+		now := l.clock.Now()
+		if l.allowN(now, n) {
+			return true, 0, l.limit, l.clock
+		}
+
+		return false, l.nextAvailableDuration(now, n), l.limit, l.clock
+	})
+}
+
+// Status reports the limit, the events allowed right now by the weighted count
+// and how long until no recorded event counts any more.
+// This method is thread-safe and does not record any event.
+func (l *SlidingWindowCounter) Status() Status {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.clock.Now()
+	l.advance(now)
+
+	status := Status{
+		Limit:     l.limit,
+		Remaining: max(int(float64(l.limit)-l.estimate(now)), 0),
+	}
+	switch {
+	case l.currCount > 0:
+		// Events of the current window keep counting through the next one
+		status.Reset = l.windowStart.Add(2 * l.interval).Sub(now)
+	case l.prevCount > 0:
+		status.Reset = l.windowStart.Add(l.interval).Sub(now)
+	}
+	if status.Remaining == 0 {
+		status.RetryAfter = l.nextAvailableDuration(now, 1)
+	}
+
+	return status
+}
+
+// SetLimit updates the maximum allowed events per window.
+// If limit <= 0, it defaults to 1.
+func (l *SlidingWindowCounter) SetLimit(limit int) {
+	if limit <= 0 {
+		limit = 1
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.clock.Now()
+	l.advance(now)
+	l.limit = limit
+}
+
+// SetInterval updates the interval duration for the rate limiter.
+// The current window keeps its start time and is resized to the new interval.
+// If interval <= 0, it defaults to 1 second.
+func (l *SlidingWindowCounter) SetInterval(interval time.Duration) {
+	if interval <= 0 {
+		interval = 1 * time.Second
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.clock.Now()
+	l.advance(now)
+	l.interval = interval
+	l.advance(now)
+}
+
+// SetClock replaces the time source of the rate limiter, e.g. with a fake clock in tests.
+// The current window restarts at the current time of c, keeping the counts.
+// A nil c restores the real clock.
+func (l *SlidingWindowCounter) SetClock(c clock.Clock) {
+	c = clock.OrDefault(c)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.clock = c
+	l.windowStart = c.Now()
+}
@@ -0,0 +1,333 @@
+package ratelimiter
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestSlidingWindowLog_AllowN tests AllowN usage and edge cases of the SlidingWindowLog.
+func TestSlidingWindowLog_AllowN(t *testing.T) {
+	tests := []struct {
+		name    string
+		limit   int
+		consume []int
+		want    []bool
+	}{
+		{
+			name:    "consume within limit",
+			limit:   3,
+			consume: []int{1, 2, 1},
+			want:    []bool{true, true, false},
+		},
+		{
+			name:    "consume zero or negative always true",
+			limit:   1,
+			consume: []int{1, 0, -1},
+			want:    []bool{true, true, true},
+		},
+		{
+			name:    "consume more than limit false",
+			limit:   2,
+			consume: []int{3, 2},
+			want:    []bool{false, true},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			l := NewSlidingWindowLog(tc.limit, time.Minute)
+			for i, n := range tc.consume {
+				if got := l.AllowN(n); got != tc.want[i] {
+					t.Errorf("AllowN(%d) = %v; want %v", n, got, tc.want[i])
+				}
+			}
+		})
+	}
+}
+
+// TestSlidingWindowLog_NoBoundaryBurst verifies that events are only allowed
+// again once the events they replace have left the window.
+func TestSlidingWindowLog_NoBoundaryBurst(t *testing.T) {
+	l := NewSlidingWindowLog(2, 100*time.Millisecond)
+
+	if !l.Allow() {
+		t.Fatal("expected first event to be allowed")
+	}
+	time.Sleep(60 * time.Millisecond)
+	if !l.Allow() {
+		t.Fatal("expected second event to be allowed")
+	}
+	if l.Allow() {
+		t.Fatal("expected third event to be denied")
+	}
+
+	// The first event has left the window, the second has not
+	time.Sleep(50 * time.Millisecond)
+	if !l.Allow() {
+		t.Fatal("expected an event once the first one expired")
+	}
+	if l.Allow() {
+		t.Fatal("expected the window to be full again")
+	}
+}
+
+func TestSlidingWindowLog_Prune(t *testing.T) {
+	l := NewSlidingWindowLog(3, time.Second)
+	now := time.Now()
+	l.events = []windowEvent{{at: now.Add(-2 * time.Second), n: 2}, {at: now.Add(-time.Second), n: 1}}
+	l.count = 3
+
+	if !l.allowN(now, 3) {
+		t.Fatal("expected expired events to be pruned")
+	}
+	if l.count != 3 || len(l.events) != 1 {
+		t.Fatalf("expected 1 entry holding 3 events, got %d entries and %d events", len(l.events), l.count)
+	}
+}
+
+func TestSlidingWindowLog_NextAvailableDuration(t *testing.T) {
+	l := NewSlidingWindowLog(3, time.Second)
+	now := time.Now()
+	l.events = []windowEvent{
+		{at: now.Add(-900 * time.Millisecond), n: 1},
+		{at: now.Add(-500 * time.Millisecond), n: 2},
+	}
+	l.count = 3
+
+	tests := []struct {
+		n    int
+		want time.Duration
+	}{
+		{1, 100 * time.Millisecond},
+		{2, 500 * time.Millisecond},
+		{3, 500 * time.Millisecond},
+		{4, 0}, // over the limit
+	}
+	for _, tc := range tests {
+		if got := l.nextAvailableDuration(now, tc.n); got != tc.want {
+			t.Errorf("nextAvailableDuration(%d) = %v; want %v", tc.n, got, tc.want)
+		}
+	}
+}
+
+func TestSlidingWindowLog_WaitN(t *testing.T) {
+	l := NewSlidingWindowLog(2, 50*time.Millisecond)
+	l.AllowN(2)
+
+	start := time.Now()
+	if err := l.WaitN(context.Background(), 1); err != nil {
+		t.Fatalf("WaitN returned error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 40*time.Millisecond {
+		t.Errorf("expected to wait for the window, waited %v", elapsed)
+	}
+
+	if err := l.WaitN(context.Background(), 3); err == nil {
+		t.Error("expected an error when waiting for more events than the limit")
+	}
+	if err := l.WaitN(context.Background(), 0); err != nil {
+		t.Errorf("expected WaitN(0) to return nil, got %v", err)
+	}
+}
+
+func TestSlidingWindowLog_WaitContextCancel(t *testing.T) {
+	l := NewSlidingWindowLog(1, time.Minute)
+	l.Allow()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	if err := l.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected DeadlineExceeded, got %v", err)
+	}
+}
+
+func TestSlidingWindowLog_Setters(t *testing.T) {
+	l := NewSlidingWindowLog(1, time.Minute)
+	l.Allow()
+
+	l.SetLimit(2)
+	if !l.Allow() {
+		t.Error("expected event to be allowed after raising the limit")
+	}
+	l.SetLimit(0)
+	if l.limit != 1 {
+		t.Errorf("expected limit to default to 1, got %d", l.limit)
+	}
+
+	l.SetInterval(10 * time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	if !l.Allow() {
+		t.Error("expected event to be allowed after shrinking the interval")
+	}
+	l.SetInterval(-1)
+	if l.interval != time.Second {
+		t.Errorf("expected interval to default to 1s, got %v", l.interval)
+	}
+}
+
+func TestNewSlidingWindowLog(t *testing.T) {
+	l := NewSlidingWindowLog(0, 0)
+	if l.limit != 1 || l.interval != time.Second {
+		t.Errorf("expected defaults 1 and 1s, got %d and %v", l.limit, l.interval)
+	}
+}
+
+// TestSlidingWindowCounter_Weighting verifies that the previous window counts
+// in proportion to its overlap with the sliding window.
+func TestSlidingWindowCounter_Weighting(t *testing.T) {
+	l := NewSlidingWindowCounter(10, time.Second)
+	now := time.Now()
+	// 25% into the current window, 75% of the previous one still overlaps
+	l.windowStart = now.Add(-250 * time.Millisecond)
+	l.prevCount = 8
+	l.currCount = 2
+
+	if got := l.estimate(now); got != 8 {
+		t.Fatalf("estimate = %v; want 8", got)
+	}
+	if !l.allowN(now, 2) {
+		t.Fatal("expected 2 events to fit")
+	}
+	if l.allowN(now, 1) {
+		t.Fatal("expected the window to be full")
+	}
+
+	// One more event needs 8*(1-x)+4+1 <= 10, so x >= 0.375: 125ms from now
+	if got := l.nextAvailableDuration(now, 1); got != 125*time.Millisecond {
+		t.Errorf("nextAvailableDuration(1) = %v; want 125ms", got)
+	}
+}
+
+func TestSlidingWindowCounter_Advance(t *testing.T) {
+	l := NewSlidingWindowCounter(10, time.Second)
+	start := l.windowStart
+	l.currCount = 5
+
+	l.advance(start.Add(1500 * time.Millisecond))
+	if l.prevCount != 5 || l.currCount != 0 || !l.windowStart.Equal(start.Add(time.Second)) {
+		t.Fatalf("unexpected state after one window: prev=%d curr=%d", l.prevCount, l.currCount)
+	}
+
+	l.currCount = 3
+	l.advance(start.Add(3500 * time.Millisecond))
+	if l.prevCount != 0 || l.currCount != 0 || !l.windowStart.Equal(start.Add(3*time.Second)) {
+		t.Fatalf("unexpected state after idle windows: prev=%d curr=%d", l.prevCount, l.currCount)
+	}
+
+	l.currCount = 4
+	l.advance(start)
+	if l.prevCount != 4 || l.currCount != 0 || !l.windowStart.Equal(start) {
+		t.Fatalf("unexpected state after clock moved back: prev=%d curr=%d", l.prevCount, l.currCount)
+	}
+}
+
+func TestSlidingWindowCounter_FullCurrentWindow(t *testing.T) {
+	l := NewSlidingWindowCounter(2, time.Second)
+	now := l.windowStart.Add(200 * time.Millisecond)
+	l.currCount = 2
+
+	if got := l.nextAvailableDuration(now, 1); got != 800*time.Millisecond {
+		t.Errorf("nextAvailableDuration(1) = %v; want 800ms", got)
+	}
+}
+
+func TestSlidingWindowCounter_AllowAndWait(t *testing.T) {
+	l := NewSlidingWindowCounter(2, 50*time.Millisecond)
+
+	if !l.Allow() || !l.Allow() {
+		t.Fatal("expected the first two events to be allowed")
+	}
+	if l.Allow() {
+		t.Fatal("expected the third event to be denied")
+	}
+	if !l.AllowN(0) {
+		t.Error("expected AllowN(0) to be true")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := l.Wait(ctx); err != nil {
+		t.Fatalf("Wait returned error: %v", err)
+	}
+	if err := l.WaitN(ctx, 3); err == nil {
+		t.Error("expected an error when waiting for more events than the limit")
+	}
+}
+
+func TestSlidingWindowCounter_WaitContextCancel(t *testing.T) {
+	l := NewSlidingWindowCounter(1, time.Minute)
+	l.Allow()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := l.Wait(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected Canceled, got %v", err)
+	}
+}
+
+func TestSlidingWindowCounter_Setters(t *testing.T) {
+	l := NewSlidingWindowCounter(1, time.Minute)
+	l.Allow()
+
+	l.SetLimit(2)
+	if !l.Allow() {
+		t.Error("expected event to be allowed after raising the limit")
+	}
+	l.SetLimit(-1)
+	if l.limit != 1 {
+		t.Errorf("expected limit to default to 1, got %d", l.limit)
+	}
+
+	l.SetInterval(0)
+	if l.interval != time.Second {
+		t.Errorf("expected interval to default to 1s, got %v", l.interval)
+	}
+}
+
+func TestNewSlidingWindowCounter(t *testing.T) {
+	l := NewSlidingWindowCounter(-5, -time.Second)
+	if l.limit != 1 || l.interval != time.Second {
+		t.Errorf("expected defaults 1 and 1s, got %d and %v", l.limit, l.interval)
+	}
+}
+
+func TestSlidingWindow_ConcurrentAccess(t *testing.T) {
+	const limit = 100
+
+	limiters := map[string]interface{ Allow() bool }{
+		"log":     NewSlidingWindowLog(limit, time.Minute),
+		"counter": NewSlidingWindowCounter(limit, time.Minute),
+	}
+	for name, l := range limiters {
+		t.Run(name, func(t *testing.T) {
+			var (
+				wg      sync.WaitGroup
+				mu      sync.Mutex
+				allowed int
+			)
+			for range 10 {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for range 50 {
+						if l.Allow() {
+							mu.Lock()
+							allowed++
+							mu.Unlock()
+						}
+					}
+				}()
+			}
+			wg.Wait()
+
+			if allowed != limit {
+				t.Errorf("expected %d allowed events, got %d", limit, allowed)
+			}
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package retry
+
+import (
+	"math"
+	randv2 "math/rand/v2"
+	"sync"
+	"time"
+)
+
+// BackoffStep is one stage of a backoff built with ChainBackoff.
+type BackoffStep struct {
+	// Backoff is used for this stage. Its attempt argument restarts at 0 at
+	// the beginning of the stage.
+	Backoff func(attempt uint) time.Duration
+	// Attempts is the number of pauses this stage covers. 0 means it covers
+	// all remaining attempts.
+	Attempts uint
+}
+
+// jitterSource draws random durations from rng, or from the global
+// math/rand/v2 source if rng is nil. A *rand.Rand is not safe for concurrent
+// use, so access to it is serialized.
+type jitterSource struct {
+	mu  sync.Mutex
+	rng *randv2.Rand
+}
+
+// between returns a random duration in [lo, hi].
+func (s *jitterSource) between(lo, hi time.Duration) time.Duration {
+	if hi <= lo {
+		return lo
+	}
+
+	n := int64(hi - lo)
+	if n < math.MaxInt64 {
+		n++
+	}
+
+	if s.rng == nil {
+		return lo + time.Duration(randv2.Int64N(n)) //nolint:gosec // jitter does not need a secure source
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return lo + time.Duration(s.rng.Int64N(n))
+}
+
+// FullJitter returns a backoff that waits a random duration between 0 and the
+// delay of backoff ("full jitter"). Pass a seeded rng for deterministic
+// delays in tests; nil uses the global math/rand/v2 source.
+func FullJitter(backoff func(attempt uint) time.Duration, rng *randv2.Rand) func(attempt uint) time.Duration {
+	src := &jitterSource{rng: rng}
+
+	return func(attempt uint) time.Duration {
+		return src.between(0, max(backoff(attempt), 0))
+	}
+}
+
+// EqualJitter returns a backoff that waits half the delay of backoff plus a
+// random duration up to the other half ("equal jitter"), which keeps a
+// minimum pause while still spreading retries out. Pass a seeded rng for
+// deterministic delays in tests; nil uses the global math/rand/v2 source.
+func EqualJitter(backoff func(attempt uint) time.Duration, rng *randv2.Rand) func(attempt uint) time.Duration {
+	src := &jitterSource{rng: rng}
+
+	return func(attempt uint) time.Duration {
+		d := max(backoff(attempt), 0)
+		half := d / 2
+
+		return d - half + src.between(0, half)
+	}
+}
+
+// DecorrelatedJitter returns a backoff that waits a random duration between
+// base and three times the previous delay, capped at maxDelay ("decorrelated
+// jitter"). Each delay depends on the previous one, which restarts from base
+// at attempt 0, so use a separate backoff for each concurrent Do call.
+// A maxDelay <= 0 means no cap. Pass a seeded rng for deterministic delays in
+// tests; nil uses the global math/rand/v2 source.
+func DecorrelatedJitter(base, maxDelay time.Duration, rng *randv2.Rand) func(attempt uint) time.Duration {
+	src := &jitterSource{rng: rng}
+	if maxDelay <= 0 {
+		maxDelay = time.Duration(math.MaxInt64)
+	}
+
+	var mu sync.Mutex
+	prev := base
+
+	return func(attempt uint) time.Duration {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if attempt == 0 {
+			prev = base
+		}
+
+		upper := time.Duration(math.MaxInt64)
+		if prev <= upper/3 {
+			upper = prev * 3
+		}
+		prev = min(src.between(base, upper), maxDelay)
+
+		return prev
+	}
+}
+
+// CapBackoff returns a backoff that waits the delay of backoff, but never
+// longer than maxDelay.
+func CapBackoff(backoff func(attempt uint) time.Duration, maxDelay time.Duration) func(attempt uint) time.Duration {
+	return func(attempt uint) time.Duration {
+		return min(backoff(attempt), maxDelay)
+	}
+}
+
+// AddBackoff returns a backoff that waits the delay of backoff plus d. The
+// result saturates at 2^63 - 1 and never goes below 0.
+func AddBackoff(backoff func(attempt uint) time.Duration, d time.Duration) func(attempt uint) time.Duration {
+	return func(attempt uint) time.Duration {
+		delay := backoff(attempt)
+		if d > 0 && delay > time.Duration(math.MaxInt64)-d {
+			return time.Duration(math.MaxInt64)
+		}
+
+		return max(delay+d, 0)
+	}
+}
+
+// ChainBackoff returns a backoff that uses each step for its number of
+// attempts, in order, e.g. a few quick fixed retries followed by an
+// exponential backoff. The last step covers all remaining attempts. Without
+// steps the backoff never waits.
+func ChainBackoff(steps ...BackoffStep) func(attempt uint) time.Duration {
+	return func(attempt uint) time.Duration {
+		for i, step := range steps {
+			if i == len(steps)-1 || step.Attempts == 0 || attempt < step.Attempts {
+				return step.Backoff(attempt)
+			}
+			attempt -= step.Attempts
+		}
+
+		return 0
+	}
+}
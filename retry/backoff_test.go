@@ -0,0 +1,135 @@
+package retry
+
+import (
+	"math"
+	randv2 "math/rand/v2"
+	"testing"
+	"time"
+)
+
+func seeded() *randv2.Rand {
+	return randv2.New(randv2.NewPCG(1, 2))
+}
+
+func TestFullJitter(t *testing.T) {
+	base := ExponentialBackoff(100 * time.Millisecond)
+	b := FullJitter(base, seeded())
+	same := FullJitter(base, seeded())
+
+	for attempt := range uint(8) {
+		got := b(attempt)
+		if got < 0 || got > base(attempt) {
+			t.Fatalf("attempt %d: expected delay in [0, %v], got %v", attempt, base(attempt), got)
+		}
+		if again := same(attempt); again != got {
+			t.Fatalf("attempt %d: expected the same seed to give %v, got %v", attempt, got, again)
+		}
+	}
+}
+
+func TestEqualJitter(t *testing.T) {
+	b := EqualJitter(FixedBackoff(time.Second), seeded())
+	for attempt := range uint(50) {
+		if got := b(attempt); got < 500*time.Millisecond || got > time.Second {
+			t.Fatalf("attempt %d: expected delay in [500ms, 1s], got %v", attempt, got)
+		}
+	}
+}
+
+func TestDecorrelatedJitter(t *testing.T) {
+	base, maxDelay := 100*time.Millisecond, 2*time.Second
+	b := DecorrelatedJitter(base, maxDelay, seeded())
+
+	prev := base
+	for attempt := range uint(30) {
+		got := b(attempt)
+		if got < base || got > min(prev*3, maxDelay) {
+			t.Fatalf("attempt %d: expected delay in [%v, %v], got %v", attempt, base, min(prev*3, maxDelay), got)
+		}
+		prev = got
+	}
+
+	if got := b(0); got > 3*base {
+		t.Fatalf("expected attempt 0 to restart from base, got %v", got)
+	}
+}
+
+func TestJitter_GlobalSource(t *testing.T) {
+	b := FullJitter(FixedBackoff(time.Second), nil)
+	if got := b(0); got < 0 || got > time.Second {
+		t.Fatalf("expected delay in [0, 1s], got %v", got)
+	}
+}
+
+func TestCapBackoff(t *testing.T) {
+	b := CapBackoff(ExponentialBackoff(100*time.Millisecond), time.Second)
+	cases := map[uint]time.Duration{
+		0:  100 * time.Millisecond,
+		3:  800 * time.Millisecond,
+		4:  time.Second,
+		70: time.Second,
+	}
+	for attempt, expected := range cases {
+		if got := b(attempt); got != expected {
+			t.Fatalf("attempt %d: expected %v, got %v", attempt, expected, got)
+		}
+	}
+}
+
+func TestAddBackoff(t *testing.T) {
+	cases := []struct {
+		backoff  func(uint) time.Duration
+		d        time.Duration
+		expected time.Duration
+	}{
+		{FixedBackoff(time.Second), 500 * time.Millisecond, 1500 * time.Millisecond},
+		{FixedBackoff(time.Second), -2 * time.Second, 0},
+		{FixedBackoff(math.MaxInt64 - 1), time.Second, math.MaxInt64},
+	}
+	for _, c := range cases {
+		if got := AddBackoff(c.backoff, c.d)(0); got != c.expected {
+			t.Fatalf("expected %v, got %v", c.expected, got)
+		}
+	}
+}
+
+func TestChainBackoff(t *testing.T) {
+	b := ChainBackoff(
+		BackoffStep{Backoff: FixedBackoff(10 * time.Millisecond), Attempts: 2},
+		BackoffStep{Backoff: ExponentialBackoff(100 * time.Millisecond), Attempts: 2},
+		BackoffStep{Backoff: FixedBackoff(time.Second), Attempts: 1},
+	)
+	cases := map[uint]time.Duration{
+		0: 10 * time.Millisecond,
+		1: 10 * time.Millisecond,
+		2: 100 * time.Millisecond,
+		3: 200 * time.Millisecond,
+		4: time.Second,
+		9: time.Second, // the last step covers the remaining attempts
+	}
+	for attempt, expected := range cases {
+		if got := b(attempt); got != expected {
+			t.Fatalf("attempt %d: expected %v, got %v", attempt, expected, got)
+		}
+	}
+
+	if got := ChainBackoff()(3); got != 0 {
+		t.Fatalf("expected no delay without steps, got %v", got)
+	}
+}
+
+func BenchmarkFullJitter(b *testing.B) {
+	backoff := FullJitter(ExponentialBackoff(100*time.Millisecond), nil)
+	for b.Loop() {
+		_ = backoff(5)
+	}
+}
+
+func BenchmarkDecorrelatedJitter(b *testing.B) {
+	backoff := DecorrelatedJitter(100*time.Millisecond, 10*time.Second, seeded())
+	var attempt uint
+	for b.Loop() {
+		_ = backoff(attempt % 10)
+		attempt++
+	}
+}
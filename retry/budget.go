@@ -0,0 +1,113 @@
+package retry
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/kashifkhan0771/utils/clock"
+)
+
+// DefaultBudgetWindow is the period over which a Budget counts requests and retries.
+const DefaultBudgetWindow = 10 * time.Second
+
+// ErrBudgetExhausted is wrapped into the error returned by Do when a retry
+// was denied by the Options.Budget.
+var ErrBudgetExhausted = errors.New("retry: budget exhausted")
+
+// budgetBucket counts the requests and retries of one second.
+type budgetBucket struct {
+	second   int64
+	requests float64
+	retries  float64
+}
+
+// Budget limits retries to a share of requests, to keep retries from
+// multiplying the load on a struggling dependency. Share one Budget between
+// every Do call that talks to the same dependency through Options.Budget.
+//
+// Over the last DefaultBudgetWindow, the number of retries may not exceed
+// ratio times the number of requests plus minPerSecond for every second of
+// the window, so low-traffic callers can still retry. It is safe for
+// concurrent use.
+type Budget struct {
+	mu           sync.Mutex
+	ratio        float64
+	minPerSecond float64
+	buckets      []budgetBucket // One per second of the window, indexed by second modulo its length
+	clock        clock.Clock
+}
+
+// NewBudget creates a Budget that allows retries for ratio of the requests
+// (e.g. 0.1 for 10%) plus minPerSecond retries per second. Negative values
+// are treated as 0.
+func NewBudget(ratio, minPerSecond float64) *Budget {
+	return &Budget{
+		ratio:        max(ratio, 0),
+		minPerSecond: max(minPerSecond, 0),
+		buckets:      make([]budgetBucket, int(DefaultBudgetWindow/time.Second)),
+		clock:        clock.New(),
+	}
+}
+
+// SetClock replaces the time source of the budget, e.g. with a fake clock in
+// tests. Requests and retries recorded so far are forgotten. A nil c restores
+// the real clock. This method is thread-safe.
+func (b *Budget) SetClock(c clock.Clock) {
+	c = clock.OrDefault(c)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.clock = c
+	clear(b.buckets)
+}
+
+// request records the first attempt of a call.
+func (b *Budget) request() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.bucket(b.clock.Now()).requests++
+}
+
+// withdraw records a retry and returns true if the budget allows it.
+func (b *Budget) withdraw() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	current := b.bucket(b.clock.Now())
+
+	var requests, retries float64
+	for _, bucket := range b.buckets {
+		if bucket.second > current.second-int64(len(b.buckets)) {
+			requests += bucket.requests
+			retries += bucket.retries
+		}
+	}
+
+	allowed := b.ratio*requests + b.minPerSecond*float64(len(b.buckets))
+	if retries+1 > allowed {
+		return false
+	}
+	current.retries++
+
+	return true
+}
+
+// bucket returns the bucket of the second containing now, clearing it if it
+// still holds an older second. Caller must hold the mutex.
+func (b *Budget) bucket(now time.Time) *budgetBucket {
+	second := now.Unix()
+	i := second % int64(len(b.buckets))
+	if i < 0 {
+		i += int64(len(b.buckets))
+	}
+
+	bucket := &b.buckets[i]
+	if bucket.second != second {
+		*bucket = budgetBucket{second: second}
+	}
+
+	return bucket
+}
@@ -0,0 +1,99 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kashifkhan0771/utils/clock"
+)
+
+func newTestBudget(ratio, minPerSecond float64) (*Budget, *clock.Fake) {
+	clk := clock.NewFake(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	b := NewBudget(ratio, minPerSecond)
+	b.SetClock(clk)
+
+	return b, clk
+}
+
+func TestBudget_Ratio(t *testing.T) {
+	b, _ := newTestBudget(0.1, 0)
+
+	for range 20 {
+		b.request()
+	}
+	if !b.withdraw() || !b.withdraw() {
+		t.Fatal("expected 2 retries for 20 requests at 10%")
+	}
+	if b.withdraw() {
+		t.Fatal("expected the third retry to be denied")
+	}
+}
+
+func TestBudget_MinPerSecond(t *testing.T) {
+	b, clk := newTestBudget(0, 0.2) // 2 retries per 10s window
+
+	if !b.withdraw() || !b.withdraw() {
+		t.Fatal("expected the minimum retries without any requests")
+	}
+	if b.withdraw() {
+		t.Fatal("expected the minimum to be exhausted")
+	}
+
+	clk.Advance(DefaultBudgetWindow)
+	if !b.withdraw() {
+		t.Fatal("expected retries to be allowed again once the window moved on")
+	}
+}
+
+func TestDo_Budget(t *testing.T) {
+	b, _ := newTestBudget(0, 0.1) // a single retry in the window
+	opts := Options{MaxAttempts: 5, Budget: b}
+
+	attempts := 0
+	_, err := Do(context.Background(), opts, func(ctx context.Context) (string, error) {
+		attempts++
+
+		return "", fmt.Errorf("fail %d", attempts)
+	})
+	if !errors.Is(err, ErrBudgetExhausted) {
+		t.Fatalf("expected ErrBudgetExhausted, got %v", err)
+	}
+	if attempts != 2 {
+		t.Fatalf("expected 1 retry, got %d attempts", attempts)
+	}
+	if err.Error() != "fail 1\nretry: budget exhausted: fail 2" {
+		t.Fatalf("unexpected message: %q", err.Error())
+	}
+}
+
+func TestHedge_Budget(t *testing.T) {
+	b, _ := newTestBudget(0, 0)
+	opts := Options{MaxAttempts: 3, Budget: b}
+
+	attempts := 0
+	_, err := Hedge(context.Background(), opts, func(ctx context.Context) (string, error) {
+		attempts++
+
+		return "", errors.New("fail")
+	})
+	if !errors.Is(err, ErrBudgetExhausted) || attempts != 1 {
+		t.Fatalf("expected ErrBudgetExhausted after 1 attempt, got %v after %d", err, attempts)
+	}
+}
+
+func TestHedge_BudgetKeepsAttemptErrors(t *testing.T) {
+	b, _ := newTestBudget(0, 0.1) // a single extra attempt in the window
+	opts := Options{MaxAttempts: 3, Budget: b, Backoff: FixedBackoff(time.Hour)}
+
+	var attempts atomic.Int32
+	_, err := Hedge(context.Background(), opts, func(ctx context.Context) (string, error) {
+		return "", fmt.Errorf("fail %d", attempts.Add(1))
+	})
+	if !errors.Is(err, ErrBudgetExhausted) || err.Error() != "fail 1\nretry: budget exhausted: fail 2" {
+		t.Fatalf("expected ErrBudgetExhausted joined to the first error, got %q", err)
+	}
+}
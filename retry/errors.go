@@ -0,0 +1,116 @@
+package retry
+
+import (
+	"errors"
+	"slices"
+	"time"
+)
+
+// PermanentError marks an error that must not be retried. See Permanent.
+type PermanentError struct {
+	Err error // The underlying error
+}
+
+// Error returns the message of the underlying error.
+func (e *PermanentError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *PermanentError) Unwrap() error {
+	return e.Err
+}
+
+// Permanent wraps err so that Do stops retrying and returns it immediately,
+// regardless of ShouldRetry. It returns nil if err is nil.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return &PermanentError{Err: err}
+}
+
+// AfterError carries the delay to wait before the next attempt. See After.
+type AfterError struct {
+	Delay time.Duration // Delay before the next attempt, replacing Backoff
+	Err   error         // The underlying error
+}
+
+// Error returns the message of the underlying error.
+func (e *AfterError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *AfterError) Unwrap() error {
+	return e.Err
+}
+
+// After wraps err so that Do waits d before the next attempt instead of the
+// delay returned by Backoff, e.g. to honour a server's Retry-After hint.
+// ShouldRetry is still consulted. It returns nil if err is nil.
+func After(d time.Duration, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return &AfterError{Delay: max(d, 0), Err: err}
+}
+
+// MaxAttemptsError is returned by Do when MaxAttempts is reached. It holds
+// the error of every attempt, in order, and works with errors.Is and
+// errors.As like the result of errors.Join.
+type MaxAttemptsError struct {
+	Errors []error // Error of each attempt
+}
+
+// Error reports the error of the last attempt.
+func (e *MaxAttemptsError) Error() string {
+	if len(e.Errors) == 0 {
+		return "retry: max attempts reached"
+	}
+
+	return "retry: max attempts reached: " + e.Errors[len(e.Errors)-1].Error()
+}
+
+// Unwrap returns the errors of all attempts.
+func (e *MaxAttemptsError) Unwrap() []error {
+	return e.Errors
+}
+
+// Last returns the error of the last attempt, or nil if no attempt was made.
+func (e *MaxAttemptsError) Last() error {
+	if len(e.Errors) == 0 {
+		return nil
+	}
+
+	return e.Errors[len(e.Errors)-1]
+}
+
+// withAttemptErrors returns err joined with the errors of the attempts before
+// it, as errors.Join does, or err as-is if there are none.
+func withAttemptErrors(err error, earlier []error) error {
+	if len(earlier) == 0 {
+		return err
+	}
+
+	return errors.Join(append(slices.Clone(earlier), err)...)
+}
+
+// retryDelay returns the delay requested with After, if any.
+func retryDelay(err error) (time.Duration, bool) {
+	var after *AfterError
+	if errors.As(err, &after) {
+		return after.Delay, true
+	}
+
+	return 0, false
+}
+
+// isPermanent reports whether err was marked with Permanent.
+func isPermanent(err error) bool {
+	var permanent *PermanentError
+
+	return errors.As(err, &permanent)
+}
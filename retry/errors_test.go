@@ -0,0 +1,117 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestPermanent(t *testing.T) {
+	if Permanent(nil) != nil {
+		t.Fatal("expected Permanent(nil) to be nil")
+	}
+
+	cause := errors.New("bad request")
+	attempts := 0
+	_, err := Do(context.Background(), defaultOpts(), func(ctx context.Context) (string, error) {
+		attempts++
+		if attempts == 2 {
+			return "", fmt.Errorf("call failed: %w", Permanent(cause))
+		}
+
+		return "", errors.New("transient")
+	})
+
+	if attempts != 2 {
+		t.Fatalf("expected 2 attempts, got %d", attempts)
+	}
+	if !errors.Is(err, cause) {
+		t.Fatalf("expected the permanent cause, got %v", err)
+	}
+	var permanent *PermanentError
+	if !errors.As(err, &permanent) || err.Error() != "transient\ncall failed: bad request" {
+		t.Fatalf("expected a *PermanentError joined to the earlier error, got %v", err)
+	}
+}
+
+func TestAfter(t *testing.T) {
+	if After(time.Second, nil) != nil {
+		t.Fatal("expected After(d, nil) to be nil")
+	}
+
+	var delays []time.Duration
+	opts := Options{
+		MaxAttempts: 3,
+		Backoff:     FixedBackoff(time.Hour),
+		OnRetry: func(err error, attempt uint, delay time.Duration) {
+			delays = append(delays, delay)
+		},
+	}
+
+	attempts := 0
+	result, err := Do(context.Background(), opts, func(ctx context.Context) (string, error) {
+		attempts++
+		if attempts < 3 {
+			return "", After(time.Duration(attempts)*time.Millisecond, errors.New("throttled"))
+		}
+
+		return "ok", nil
+	})
+	if err != nil || result != "ok" {
+		t.Fatalf("expected ok, got %q, %v", result, err)
+	}
+	if fmt.Sprint(delays) != "[1ms 2ms]" {
+		t.Fatalf("expected the delays from After, got %v", delays)
+	}
+}
+
+func TestAfter_ShouldRetryStillApplies(t *testing.T) {
+	throttled := errors.New("throttled")
+	opts := defaultOpts()
+	opts.ShouldRetry = func(err error) bool { return !errors.Is(err, throttled) }
+
+	attempts := 0
+	_, err := Do(context.Background(), opts, func(ctx context.Context) (string, error) {
+		attempts++
+
+		return "", After(time.Millisecond, throttled)
+	})
+	if attempts != 1 || !errors.Is(err, throttled) {
+		t.Fatalf("expected ShouldRetry to stop after 1 attempt, got %d attempts and %v", attempts, err)
+	}
+}
+
+func TestMaxAttemptsError(t *testing.T) {
+	first := errors.New("first")
+	second := errors.New("second")
+	third := errors.New("third")
+	errs := []error{first, second, third}
+
+	attempts := 0
+	_, err := Do(context.Background(), defaultOpts(), func(ctx context.Context) (string, error) {
+		attempts++
+
+		return "", errs[attempts-1]
+	})
+
+	var maxErr *MaxAttemptsError
+	if !errors.As(err, &maxErr) {
+		t.Fatalf("expected a *MaxAttemptsError, got %T", err)
+	}
+	if len(maxErr.Errors) != 3 || maxErr.Last() != third {
+		t.Fatalf("expected all 3 attempt errors, got %v", maxErr.Errors)
+	}
+	for _, e := range errs {
+		if !errors.Is(err, e) {
+			t.Fatalf("expected errors.Is to find %v", e)
+		}
+	}
+	if err.Error() != "retry: max attempts reached: third" {
+		t.Fatalf("unexpected message: %q", err.Error())
+	}
+	if (&MaxAttemptsError{}).Last() != nil {
+		t.Fatal("expected Last() to be nil without errors")
+	}
+}
@@ -0,0 +1,162 @@
+package retry
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/kashifkhan0771/utils/clock"
+)
+
+// Hedge calls fn and, if it has not succeeded after the delay returned by
+// opts.Backoff, starts another attempt alongside it, up to opts.MaxAttempts
+// attempts in flight. The first successful result is returned and the
+// contexts of the other attempts are cancelled. Use it for idempotent,
+// latency-sensitive reads.
+//
+// Options are interpreted as follows:
+//   - Backoff(n) is the delay after starting attempt n+1 before the next one
+//     is started. A nil Backoff starts all attempts at once.
+//   - A failed attempt starts the next one immediately, unless its error is
+//     Permanent or rejected by ShouldRetry, in which case Hedge returns it,
+//     joined to the errors of the attempts that failed before, as Do does.
+//   - TotalTimeout, AttemptTimeout, Budget, Clock and OnGiveUp apply as in Do;
+//     OnRetry is called when a failed attempt starts the next one.
+//
+// If every attempt fails, the returned *MaxAttemptsError holds their errors
+// in the order the attempts were started. When Hedge stops early because of
+// the context or the Budget, the errors of the failed attempts are joined to
+// the error that stopped it.
+func Hedge[T any](ctx context.Context, opts Options, fn RetryFunc[T]) (T, error) {
+	var zero T
+	if ctx == nil {
+		return zero, fmt.Errorf("retry: context must not be nil")
+	}
+
+	if opts.TotalTimeout > 0 {
+		var cls context.CancelFunc
+		ctx, cls = context.WithTimeout(ctx, opts.TotalTimeout)
+		defer cls()
+	}
+	// Cancels the attempts still in flight once a result is chosen
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	shouldRetry := opts.ShouldRetry
+	if shouldRetry == nil {
+		shouldRetry = func(error) bool { return true }
+	}
+	backoff := opts.Backoff
+	if backoff == nil {
+		backoff = func(uint) time.Duration { return 0 }
+	}
+
+	giveUp := func(err error, attempts uint) (T, error) {
+		if opts.OnGiveUp != nil {
+			opts.OnGiveUp(err, attempts)
+		}
+
+		return zero, err
+	}
+
+	if opts.MaxAttempts == 0 {
+		return giveUp(&MaxAttemptsError{}, 0)
+	}
+
+	type result struct {
+		attempt uint
+		value   T
+		err     error
+	}
+	results := make(chan result, opts.MaxAttempts) // never blocks abandoned attempts
+
+	var started, pending uint
+	exhausted := false // The budget denied an attempt
+	launch := func() {
+		started++
+		pending++
+		attempt := started
+		go func() {
+			value, err := callAttempt(ctx, opts.AttemptTimeout, attempt, fn)
+			results <- result{attempt: attempt, value: value, err: err}
+		}()
+	}
+	// canHedge reports whether another attempt may be started, consulting
+	// the retry budget.
+	canHedge := func() bool {
+		if started >= opts.MaxAttempts || exhausted {
+			return false
+		}
+		if opts.Budget != nil && !opts.Budget.withdraw() {
+			exhausted = true
+
+			return false
+		}
+
+		return true
+	}
+
+	if opts.Budget != nil {
+		opts.Budget.request()
+	}
+	launch()
+
+	timer := clock.OrDefault(opts.Clock).NewTimer(backoff(0))
+	defer timer.Stop()
+
+	errs := make([]error, opts.MaxAttempts)
+	// failed returns the errors of the finished attempts other than except,
+	// in the order the attempts were started.
+	failed := func(except uint) []error {
+		var earlier []error
+		for i, err := range errs[:started] {
+			if err != nil && uint(i+1) != except {
+				earlier = append(earlier, err)
+			}
+		}
+
+		return earlier
+	}
+
+	for {
+		var hedge <-chan time.Time
+		if started < opts.MaxAttempts && !exhausted {
+			hedge = timer.C()
+		}
+
+		select {
+		case r := <-results:
+			pending--
+			if r.err == nil {
+				return r.value, nil
+			}
+			errs[r.attempt-1] = r.err
+
+			if isPermanent(r.err) || !shouldRetry(r.err) {
+				return giveUp(withAttemptErrors(r.err, failed(r.attempt)), started)
+			}
+			if canHedge() {
+				if opts.OnRetry != nil {
+					opts.OnRetry(r.err, r.attempt, 0)
+				}
+				launch()
+				timer.Reset(backoff(started - 1))
+			} else if pending == 0 {
+				if exhausted {
+					budgetErr := fmt.Errorf("%w: %w", ErrBudgetExhausted, r.err)
+
+					return giveUp(withAttemptErrors(budgetErr, failed(r.attempt)), started)
+				}
+
+				return giveUp(&MaxAttemptsError{Errors: errs[:started]}, started)
+			}
+		case <-hedge:
+			if canHedge() {
+				launch()
+				timer.Reset(backoff(started - 1))
+			}
+		case <-ctx.Done():
+			return giveUp(withAttemptErrors(ctx.Err(), failed(0)), started)
+		}
+	}
+}
@@ -0,0 +1,204 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kashifkhan0771/utils/clock"
+)
+
+func TestHedge_SlowFirstAttempt(t *testing.T) {
+	var cancelled atomic.Bool
+	opts := Options{MaxAttempts: 3, Backoff: FixedBackoff(20 * time.Millisecond)}
+
+	start := time.Now()
+	result, err := Hedge(context.Background(), opts, func(ctx context.Context) (string, error) {
+		if Attempt(ctx) == 1 {
+			<-ctx.Done() // the first attempt hangs until it loses
+			cancelled.Store(true)
+
+			return "", ctx.Err()
+		}
+
+		return "hedged", nil
+	})
+	if err != nil || result != "hedged" {
+		t.Fatalf("expected the hedged result, got %q, %v", result, err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("expected the hedge to finish quickly, took %v", elapsed)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for !cancelled.Load() && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if !cancelled.Load() {
+		t.Fatal("expected the losing attempt to be cancelled")
+	}
+}
+
+func TestHedge_FastFirstAttempt(t *testing.T) {
+	var calls atomic.Int32
+	opts := Options{MaxAttempts: 3, Backoff: FixedBackoff(time.Hour)}
+
+	result, err := Hedge(context.Background(), opts, func(ctx context.Context) (string, error) {
+		calls.Add(1)
+
+		return "first", nil
+	})
+	if err != nil || result != "first" {
+		t.Fatalf("expected the first result, got %q, %v", result, err)
+	}
+	if calls.Load() != 1 {
+		t.Fatalf("expected no hedge, got %d calls", calls.Load())
+	}
+}
+
+func TestHedge_FailureStartsNextAttempt(t *testing.T) {
+	var retries []uint
+	opts := Options{
+		MaxAttempts: 3,
+		Backoff:     FixedBackoff(time.Hour),
+		OnRetry:     func(err error, attempt uint, delay time.Duration) { retries = append(retries, attempt) },
+	}
+
+	result, err := Hedge(context.Background(), opts, func(ctx context.Context) (string, error) {
+		if Attempt(ctx) < 3 {
+			return "", errors.New("fail")
+		}
+
+		return "third", nil
+	})
+	if err != nil || result != "third" {
+		t.Fatalf("expected the third result, got %q, %v", result, err)
+	}
+	if len(retries) != 2 {
+		t.Fatalf("expected 2 retries, got %v", retries)
+	}
+}
+
+func TestHedge_Clock(t *testing.T) {
+	clk := clock.NewFake(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	opts := Options{MaxAttempts: 2, Backoff: FixedBackoff(time.Hour), Clock: clk}
+
+	done := make(chan string, 1)
+	go func() {
+		result, _ := Hedge(context.Background(), opts, func(ctx context.Context) (string, error) {
+			if Attempt(ctx) == 1 {
+				<-ctx.Done()
+
+				return "", ctx.Err()
+			}
+
+			return "hedged", nil
+		})
+		done <- result
+	}()
+
+	// The hedge starts once the fake clock reaches the delay
+	clk.WaitForTimers(1)
+	clk.Advance(time.Hour)
+	if result := <-done; result != "hedged" {
+		t.Fatalf("expected the hedged result, got %q", result)
+	}
+}
+
+func TestHedge_AllFail(t *testing.T) {
+	opts := Options{MaxAttempts: 3}
+
+	_, err := Hedge(context.Background(), opts, func(ctx context.Context) (string, error) {
+		return "", errors.New("fail")
+	})
+
+	var maxErr *MaxAttemptsError
+	if !errors.As(err, &maxErr) || len(maxErr.Errors) != 3 {
+		t.Fatalf("expected a *MaxAttemptsError with 3 errors, got %v", err)
+	}
+}
+
+func TestHedge_Permanent(t *testing.T) {
+	cause := errors.New("not found")
+	var giveUps atomic.Int32
+	opts := Options{
+		MaxAttempts: 3,
+		Backoff:     FixedBackoff(time.Hour),
+		OnGiveUp:    func(error, uint) { giveUps.Add(1) },
+	}
+
+	_, err := Hedge(context.Background(), opts, func(ctx context.Context) (string, error) {
+		return "", Permanent(cause)
+	})
+	if !errors.Is(err, cause) || giveUps.Load() != 1 {
+		t.Fatalf("expected the permanent error and a single give up, got %v and %d", err, giveUps.Load())
+	}
+}
+
+func TestHedge_PermanentKeepsAttemptErrors(t *testing.T) {
+	cause := errors.New("not found")
+	opts := Options{MaxAttempts: 3, Backoff: FixedBackoff(time.Hour)}
+
+	_, err := Hedge(context.Background(), opts, func(ctx context.Context) (string, error) {
+		if Attempt(ctx) == 1 {
+			return "", errors.New("transient")
+		}
+
+		return "", Permanent(cause)
+	})
+	if !errors.Is(err, cause) || err.Error() != "transient\nnot found" {
+		t.Fatalf("expected the permanent error joined to the first error, got %q", err)
+	}
+}
+
+func TestHedge_TotalTimeoutKeepsAttemptErrors(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	opts := Options{MaxAttempts: 2, TotalTimeout: 20 * time.Millisecond, Backoff: FixedBackoff(time.Hour)}
+
+	_, err := Hedge(context.Background(), opts, func(ctx context.Context) (string, error) {
+		if Attempt(ctx) == 1 {
+			return "", errors.New("transient")
+		}
+		<-release
+
+		return "", nil
+	})
+	if !errors.Is(err, context.DeadlineExceeded) || err.Error() != "transient\ncontext deadline exceeded" {
+		t.Fatalf("expected DeadlineExceeded joined to the first error, got %q", err)
+	}
+}
+
+func TestHedge_TotalTimeout(t *testing.T) {
+	opts := Options{MaxAttempts: 2, TotalTimeout: 20 * time.Millisecond}
+
+	_, err := Hedge(context.Background(), opts, func(ctx context.Context) (string, error) {
+		<-ctx.Done()
+
+		return "", ctx.Err()
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected DeadlineExceeded, got %v", err)
+	}
+}
+
+func TestHedge_NilContext(t *testing.T) {
+	//nolint:staticcheck // testing nil context handling
+	if _, err := Hedge(nil, Options{MaxAttempts: 1}, func(ctx context.Context) (string, error) {
+		return "", nil
+	}); err == nil {
+		t.Fatal("expected an error for a nil context")
+	}
+}
+
+func BenchmarkHedge_FirstSucceeds(b *testing.B) {
+	opts := Options{MaxAttempts: 3, Backoff: FixedBackoff(time.Hour)}
+	ctx := context.Background()
+	for b.Loop() {
+		_, _ = Hedge(ctx, opts, func(ctx context.Context) (int, error) {
+			return 1, nil
+		})
+	}
+}
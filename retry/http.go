@@ -0,0 +1,275 @@
+package retry
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kashifkhan0771/utils/clock"
+)
+
+// DefaultHTTPMaxAttempts is the number of attempts made by a Transport whose
+// MaxAttempts is not set.
+const DefaultHTTPMaxAttempts = 3
+
+// defaultRetryStatusCodes are the response status codes retried by default.
+var defaultRetryStatusCodes = []int{
+	http.StatusTooManyRequests,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+// maxDrainBytes is how much of a discarded response body is read so that the
+// connection can be reused.
+const maxDrainBytes = 4 << 10
+
+// HTTPOptions configures a Transport.
+type HTTPOptions struct {
+	// Options controls attempts, backoff and hooks. MaxAttempts defaults to
+	// DefaultHTTPMaxAttempts. TotalTimeout and AttemptTimeout also bound
+	// reading the body of the returned response. ShouldRetry receives
+	// network errors and *StatusError values, which are wrapped with After
+	// when the server sent Retry-After; use errors.As to inspect them.
+	Options
+
+	// StatusCodes are the response status codes that are retried. Defaults
+	// to 429, 502, 503 and 504.
+	StatusCodes []int
+
+	// RetryNonIdempotent also retries methods that are not idempotent, such
+	// as POST and PATCH. Requests with an Idempotency-Key header are always
+	// retried.
+	RetryNonIdempotent bool
+
+	// MaxRetryAfter caps the delay taken from a Retry-After header. 0 means
+	// no cap; TotalTimeout still applies.
+	MaxRetryAfter time.Duration
+}
+
+// StatusError is the error passed to ShouldRetry and the hooks when a
+// response has a retryable status code.
+type StatusError struct {
+	StatusCode int           // Status code of the response
+	RetryAfter time.Duration // Delay requested by the Retry-After header, or 0
+}
+
+// Error implements the error interface.
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("retry: server responded with %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+}
+
+// Transport is an http.RoundTripper that retries requests with Do. Requests
+// are retried on network errors and on the configured status codes, but only
+// for idempotent methods unless RetryNonIdempotent is set. Request bodies are
+// replayed with Request.GetBody; requests whose body cannot be replayed are
+// sent once. A Retry-After header (in seconds or as an HTTP date) replaces
+// the backoff before the next attempt. When the last attempt gets a retryable
+// status, its response is returned as-is, and so is the latest one when
+// ShouldRetry or the Budget stops the retries early. OnGiveUp is not called
+// when a response is returned.
+type Transport struct {
+	base http.RoundTripper
+	opts HTTPOptions
+}
+
+// NewTransport returns a Transport that sends requests through base, or
+// http.DefaultTransport if base is nil.
+func NewTransport(base http.RoundTripper, opts HTTPOptions) *Transport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if opts.MaxAttempts == 0 {
+		opts.MaxAttempts = DefaultHTTPMaxAttempts
+	}
+	if opts.StatusCodes == nil {
+		opts.StatusCodes = defaultRetryStatusCodes
+	}
+
+	return &Transport{base: base, opts: opts}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !t.retryable(req) {
+		return t.base.RoundTrip(req)
+	}
+
+	// The timeouts are applied here rather than by Do, so that they keep
+	// running until the response body is closed.
+	opts := t.opts.Options
+	opts.TotalTimeout, opts.AttemptTimeout = 0, 0
+
+	var ctx context.Context
+	var cancel context.CancelFunc
+	if t.opts.TotalTimeout > 0 {
+		ctx, cancel = context.WithTimeout(req.Context(), t.opts.TotalTimeout)
+	} else {
+		ctx, cancel = context.WithCancel(req.Context())
+	}
+
+	// last is the latest response with a retryable status. It is only
+	// discarded once a retry is certain, so that it can be returned if Do
+	// gives up for another reason than the context.
+	var last *http.Response
+	onRetry, onGiveUp := opts.OnRetry, opts.OnGiveUp
+	opts.OnRetry = func(err error, attempt uint, delay time.Duration) {
+		discard(last)
+		last = nil
+		if onRetry != nil {
+			onRetry(err, attempt, delay)
+		}
+	}
+	opts.OnGiveUp = func(err error, attempts uint) {
+		if last != nil && ctx.Err() == nil {
+			return
+		}
+		discard(last)
+		last = nil
+		if onGiveUp != nil {
+			onGiveUp(err, attempts)
+		}
+	}
+
+	resp, err := Do(ctx, opts, func(ctx context.Context) (*http.Response, error) {
+		resp, err := t.attempt(ctx, req)
+		if err != nil && resp != nil {
+			last = resp
+
+			return nil, err
+		}
+
+		return resp, err
+	})
+	if err != nil {
+		if last == nil {
+			cancel()
+
+			return nil, err
+		}
+		resp = last
+	}
+	resp.Body = &cancelBody{ReadCloser: resp.Body, cancel: cancel}
+
+	return resp, nil
+}
+
+// attempt sends one copy of req. Responses with a retryable status are
+// returned along with a *StatusError, wrapped with After if the server sent
+// Retry-After, except on the last attempt.
+func (t *Transport) attempt(ctx context.Context, req *http.Request) (*http.Response, error) {
+	attempt := Attempt(ctx)
+	cancel := context.CancelFunc(func() {})
+	if t.opts.AttemptTimeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, t.opts.AttemptTimeout)
+	}
+
+	r := req.Clone(ctx)
+	if attempt > 1 && req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			cancel()
+
+			return nil, err
+		}
+		r.Body = body
+	}
+
+	resp, err := t.base.RoundTrip(r)
+	if err != nil {
+		cancel()
+
+		return nil, err
+	}
+
+	if !slices.Contains(t.opts.StatusCodes, resp.StatusCode) || attempt >= t.opts.MaxAttempts {
+		resp.Body = &cancelBody{ReadCloser: resp.Body, cancel: cancel}
+
+		return resp, nil
+	}
+
+	retryAfter := parseRetryAfter(resp.Header.Get("Retry-After"), clock.OrDefault(t.opts.Clock).Now())
+	if t.opts.MaxRetryAfter > 0 {
+		retryAfter = min(retryAfter, t.opts.MaxRetryAfter)
+	}
+	resp.Body = &cancelBody{ReadCloser: resp.Body, cancel: cancel}
+
+	err = &StatusError{StatusCode: resp.StatusCode, RetryAfter: retryAfter}
+	if retryAfter > 0 {
+		return resp, After(retryAfter, err)
+	}
+
+	return resp, err
+}
+
+// discard reads a little of the body of resp, so that the connection can be
+// reused, and closes it. resp may be nil.
+func discard(resp *http.Response) {
+	if resp == nil {
+		return
+	}
+	_, _ = io.CopyN(io.Discard, resp.Body, maxDrainBytes)
+	_ = resp.Body.Close()
+}
+
+// retryable reports whether req may be sent more than once.
+func (t *Transport) retryable(req *http.Request) bool {
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return false
+	}
+	if t.opts.RetryNonIdempotent {
+		return true
+	}
+
+	switch req.Method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	}
+
+	return req.Header.Get("Idempotency-Key") != "" || req.Header.Get("X-Idempotency-Key") != ""
+}
+
+// parseRetryAfter returns the delay requested by a Retry-After header value,
+// given in seconds or as an HTTP date, or 0 if it is missing or invalid.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		if seconds <= 0 {
+			return 0
+		}
+		if seconds > int64(time.Duration(1<<63-1)/time.Second) {
+			return time.Duration(1<<63 - 1)
+		}
+
+		return time.Duration(seconds) * time.Second
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		return max(date.Sub(now), 0)
+	}
+
+	return 0
+}
+
+// cancelBody cancels the context of a request when its response body is closed.
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+// Close closes the body and releases the request's context.
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+
+	return err
+}
@@ -0,0 +1,315 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// flakyServer fails the first failures requests with status and then
+// responds with 200 and the request body.
+func flakyServer(t *testing.T, failures int32, status int, header http.Header) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if calls.Add(1) <= failures {
+			for k, v := range header {
+				w.Header()[k] = v
+			}
+			w.WriteHeader(status)
+
+			return
+		}
+		_, _ = w.Write(append([]byte("ok:"), body...))
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv, &calls
+}
+
+func testClient(opts HTTPOptions) *http.Client {
+	return &http.Client{Transport: NewTransport(nil, opts)}
+}
+
+func readBody(t *testing.T, resp *http.Response) string {
+	t.Helper()
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("failed to read body: %v", err)
+	}
+
+	return string(body)
+}
+
+func TestTransport_RetriesStatusCodes(t *testing.T) {
+	srv, calls := flakyServer(t, 2, http.StatusServiceUnavailable, nil)
+
+	resp, err := testClient(HTTPOptions{}).Get(srv.URL)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if resp.StatusCode != http.StatusOK || readBody(t, resp) != "ok:" {
+		t.Fatalf("expected a successful response, got %d", resp.StatusCode)
+	}
+	if calls.Load() != 3 {
+		t.Fatalf("expected 3 calls, got %d", calls.Load())
+	}
+}
+
+func TestTransport_ReturnsLastResponse(t *testing.T) {
+	srv, calls := flakyServer(t, 10, http.StatusBadGateway, nil)
+
+	var giveUps int
+	resp, err := testClient(HTTPOptions{Options: Options{
+		MaxAttempts: 2,
+		OnGiveUp:    func(error, uint) { giveUps++ },
+	}}).Get(srv.URL)
+	if err != nil {
+		t.Fatalf("expected the last response, got %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadGateway {
+		t.Fatalf("expected 502, got %d", resp.StatusCode)
+	}
+	if calls.Load() != 2 {
+		t.Fatalf("expected 2 calls, got %d", calls.Load())
+	}
+	if giveUps != 0 {
+		t.Fatalf("expected OnGiveUp not to be called when a response is returned, got %d", giveUps)
+	}
+}
+
+func TestTransport_ReturnsResponseWhenRetriesStop(t *testing.T) {
+	tests := []struct {
+		name string
+		opts Options
+	}{
+		{name: "budget exhausted", opts: Options{Budget: NewBudget(0, 0)}},
+		{name: "should not retry", opts: Options{ShouldRetry: func(error) bool { return false }}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls.Add(1)
+				w.WriteHeader(http.StatusServiceUnavailable)
+				_, _ = w.Write([]byte("overloaded"))
+			}))
+			t.Cleanup(srv.Close)
+
+			var giveUps int
+			tt.opts.OnGiveUp = func(error, uint) { giveUps++ }
+			resp, err := testClient(HTTPOptions{Options: tt.opts}).Get(srv.URL)
+			if err != nil {
+				t.Fatalf("expected the server response, got %v", err)
+			}
+			if resp.StatusCode != http.StatusServiceUnavailable || readBody(t, resp) != "overloaded" {
+				t.Fatalf("expected the unread 503 response, got %d", resp.StatusCode)
+			}
+			if calls.Load() != 1 {
+				t.Fatalf("expected 1 call, got %d", calls.Load())
+			}
+			if giveUps != 0 {
+				t.Fatalf("expected OnGiveUp not to be called when a response is returned, got %d", giveUps)
+			}
+		})
+	}
+}
+
+func TestTransport_DoesNotRetryOtherStatusCodes(t *testing.T) {
+	srv, calls := flakyServer(t, 1, http.StatusInternalServerError, nil)
+
+	resp, err := testClient(HTTPOptions{}).Get(srv.URL)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusInternalServerError || calls.Load() != 1 {
+		t.Fatalf("expected a single 500 response, got %d after %d calls", resp.StatusCode, calls.Load())
+	}
+
+	srv, calls = flakyServer(t, 1, http.StatusInternalServerError, nil)
+	resp, err = testClient(HTTPOptions{StatusCodes: []int{http.StatusInternalServerError}}).Get(srv.URL)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || calls.Load() != 2 {
+		t.Fatalf("expected the configured status to be retried, got %d after %d calls", resp.StatusCode, calls.Load())
+	}
+}
+
+func TestTransport_Idempotency(t *testing.T) {
+	tests := []struct {
+		name      string
+		opts      HTTPOptions
+		header    string
+		wantCalls int32
+	}{
+		{name: "POST is not retried by default", wantCalls: 1},
+		{name: "POST is retried when allowed", opts: HTTPOptions{RetryNonIdempotent: true}, wantCalls: 2},
+		{name: "POST with an idempotency key is retried", header: "key-1", wantCalls: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, calls := flakyServer(t, 1, http.StatusServiceUnavailable, nil)
+
+			req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, srv.URL, strings.NewReader("payload"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.header != "" {
+				req.Header.Set("Idempotency-Key", tt.header)
+			}
+
+			resp, err := testClient(tt.opts).Do(req)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			body := readBody(t, resp)
+			if calls.Load() != tt.wantCalls {
+				t.Fatalf("expected %d calls, got %d", tt.wantCalls, calls.Load())
+			}
+			if tt.wantCalls > 1 && body != "ok:payload" {
+				t.Fatalf("expected the body to be replayed, got %q", body)
+			}
+		})
+	}
+}
+
+func TestTransport_BodyWithoutGetBodyIsSentOnce(t *testing.T) {
+	srv, calls := flakyServer(t, 1, http.StatusServiceUnavailable, nil)
+
+	// io.MultiReader is not a type http.NewRequest knows how to rewind
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPut, srv.URL, io.MultiReader(strings.NewReader("payload")))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := testClient(HTTPOptions{}).Do(req)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable || calls.Load() != 1 {
+		t.Fatalf("expected a single attempt, got %d after %d calls", resp.StatusCode, calls.Load())
+	}
+}
+
+func TestTransport_RetryAfter(t *testing.T) {
+	srv, calls := flakyServer(t, 1, http.StatusTooManyRequests, http.Header{"Retry-After": {"120"}})
+
+	var delays []time.Duration
+	var statusErr *StatusError
+	resp, err := testClient(HTTPOptions{
+		Options: Options{
+			Backoff:      FixedBackoff(time.Hour),
+			TotalTimeout: 5 * time.Second,
+			OnRetry: func(err error, attempt uint, delay time.Duration) {
+				errors.As(err, &statusErr)
+				delays = append(delays, delay)
+			},
+		},
+		MaxRetryAfter: 10 * time.Millisecond,
+	}).Get(srv.URL)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	resp.Body.Close()
+
+	if calls.Load() != 2 {
+		t.Fatalf("expected 2 calls, got %d", calls.Load())
+	}
+	if len(delays) != 1 || delays[0] != 10*time.Millisecond {
+		t.Fatalf("expected the capped Retry-After delay, got %v", delays)
+	}
+	if statusErr == nil || statusErr.StatusCode != http.StatusTooManyRequests || statusErr.RetryAfter != 10*time.Millisecond {
+		t.Fatalf("expected a *StatusError for 429, got %v", statusErr)
+	}
+}
+
+func TestTransport_NetworkErrors(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			conn, _, err := w.(http.Hijacker).Hijack()
+			if err == nil {
+				conn.Close() // drop the connection without a response
+			}
+
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	resp, err := testClient(HTTPOptions{}).Get(srv.URL)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if readBody(t, resp) != "ok" || calls.Load() != 2 {
+		t.Fatalf("expected success on the second call, got %d calls", calls.Load())
+	}
+}
+
+func TestTransport_AttemptTimeoutKeepsBodyReadable(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			select {
+			case <-r.Context().Done():
+			case <-time.After(time.Second):
+			}
+
+			return
+		}
+		w.(http.Flusher).Flush()
+		time.Sleep(30 * time.Millisecond) // body arrives after RoundTrip returned
+		_, _ = w.Write([]byte("slow body"))
+	}))
+	defer srv.Close()
+
+	resp, err := testClient(HTTPOptions{Options: Options{
+		AttemptTimeout: 100 * time.Millisecond,
+		TotalTimeout:   5 * time.Second,
+	}}).Get(srv.URL)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if got := readBody(t, resp); got != "slow body" {
+		t.Fatalf("expected the body to be readable, got %q", got)
+	}
+	if calls.Load() != 2 {
+		t.Fatalf("expected the hung attempt to be retried, got %d calls", calls.Load())
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2025, 1, 9, 12, 0, 0, 0, time.UTC)
+	cases := map[string]time.Duration{
+		"":                              0,
+		"5":                             5 * time.Second,
+		" 0 ":                           0,
+		"-3":                            0,
+		"soon":                          0,
+		"Thu, 09 Jan 2025 12:00:30 GMT": 30 * time.Second,
+		"Thu, 09 Jan 2025 11:59:00 GMT": 0,
+	}
+	for value, expected := range cases {
+		if got := parseRetryAfter(value, now); got != expected {
+			t.Fatalf("%q: expected %v, got %v", value, expected, got)
+		}
+	}
+}
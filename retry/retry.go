@@ -5,11 +5,17 @@ import (
 	"fmt"
 	"math"
 	"time"
+
+	"github.com/kashifkhan0771/utils/clock"
 )
 
 type Options struct {
 	MaxAttempts  uint
 	TotalTimeout time.Duration
+	// AttemptTimeout bounds each call to fn. Every attempt gets a child
+	// context with this timeout; 0 means attempts are only bounded by
+	// TotalTimeout.
+	AttemptTimeout time.Duration
 	// Backoff returns how long to wait before the next attempt.
 	// attempt is zero-indexed (0 = pause after the first failure).
 	Backoff func(attempt uint) time.Duration
@@ -17,12 +23,46 @@ type Options struct {
 	// ShouldRetry reports whether the given error is retryable.
 	// Return false to abort immediately.
 	ShouldRetry func(err error) bool
+
+	// OnRetry is called after a failed attempt, before waiting delay for the
+	// next one. attempt is the one-indexed number of the attempt that failed.
+	OnRetry func(err error, attempt uint, delay time.Duration)
+
+	// OnGiveUp is called once when Do stops retrying without success, with
+	// the error Do returns and the number of attempts made.
+	OnGiveUp func(err error, attempts uint)
+
+	// Budget, if set, is consulted before every retry. When it denies one,
+	// Do stops with an error wrapping ErrBudgetExhausted and the last error.
+	// Share a Budget between calls to protect a dependency from retry storms.
+	Budget *Budget
+
+	// Clock is used to wait between attempts; nil means the real clock.
+	// Set a fake clock to test backoff without sleeping. TotalTimeout and
+	// AttemptTimeout are context deadlines and always follow the real clock.
+	Clock clock.Clock
+}
+
+// attemptKey is the context key under which Do stores the attempt number.
+type attemptKey struct{}
+
+// Attempt returns the one-indexed number of the current attempt from a
+// context passed to a RetryFunc by Do, or 0 if ctx does not come from Do.
+func Attempt(ctx context.Context) uint {
+	attempt, _ := ctx.Value(attemptKey{}).(uint)
+
+	return attempt
 }
 
 type RetryFunc[T any] func(ctx context.Context) (T, error)
 
-// Do calls fn repeatedly until fn succeeds, ShouldRetry returns false,
-// MaxAttempts is reached, or TotalTimeout elapses.
+// Do calls fn repeatedly until fn succeeds, fn returns an error wrapped with
+// Permanent, ShouldRetry returns false, MaxAttempts is reached, or
+// TotalTimeout elapses. When MaxAttempts is reached, the returned
+// *MaxAttemptsError holds the error of every attempt. When Do stops early,
+// the errors of the earlier attempts are joined to the error that stopped it. Each call receives a
+// context carrying the attempt number (see Attempt), bounded by
+// AttemptTimeout if set.
 func Do[T any](ctx context.Context, opts Options, fn RetryFunc[T]) (T, error) {
 	var zero T
 	if ctx == nil {
@@ -44,26 +84,68 @@ func Do[T any](ctx context.Context, opts Options, fn RetryFunc[T]) (T, error) {
 		backoff = func(uint) time.Duration { return 0 }
 	}
 
-	var lastErr error
+	giveUp := func(err error, attempts uint) (T, error) {
+		if opts.OnGiveUp != nil {
+			opts.OnGiveUp(err, attempts)
+		}
+
+		return zero, err
+	}
+
+	if opts.Budget != nil && opts.MaxAttempts > 0 {
+		opts.Budget.request()
+	}
+
+	var errs []error
 	for attempt := range opts.MaxAttempts {
-		ret, err := fn(ctx)
+		ret, err := callAttempt(ctx, opts.AttemptTimeout, attempt+1, fn)
 		if err == nil {
 			return ret, nil
 		}
-		lastErr = err
+		errs = append(errs, err)
+
+		if isPermanent(err) || !shouldRetry(err) {
+			return giveUp(withAttemptErrors(err, errs[:attempt]), attempt+1)
+		}
+		if attempt+1 == opts.MaxAttempts {
+			break
+		}
+		if opts.Budget != nil && !opts.Budget.withdraw() {
+			return giveUp(withAttemptErrors(fmt.Errorf("%w: %w", ErrBudgetExhausted, err), errs[:attempt]), attempt+1)
+		}
 
-		if !shouldRetry(err) {
-			return zero, err
+		delay, ok := retryDelay(err)
+		if !ok {
+			delay = backoff(attempt)
+		}
+		if opts.OnRetry != nil {
+			opts.OnRetry(err, attempt+1, delay)
 		}
 
+		timer := clock.OrDefault(opts.Clock).NewTimer(delay)
 		select {
-		case <-time.After(backoff(attempt)):
+		case <-timer.C():
 		case <-ctx.Done():
-			return zero, ctx.Err()
+			timer.Stop()
+
+			return giveUp(withAttemptErrors(ctx.Err(), errs), attempt+1)
 		}
 	}
 
-	return zero, fmt.Errorf("retry: max attempts reached: %w", lastErr)
+	return giveUp(&MaxAttemptsError{Errors: errs}, opts.MaxAttempts)
+}
+
+// callAttempt calls fn with a context carrying the attempt number and
+// bounded by timeout, if positive.
+func callAttempt[T any](ctx context.Context, timeout time.Duration, attempt uint, fn RetryFunc[T]) (T, error) {
+	ctx = context.WithValue(ctx, attemptKey{}, attempt)
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	return fn(ctx)
 }
 
 // DoVoid is a convenience wrapper around [Do] for operations that return no value.
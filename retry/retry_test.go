@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"testing"
 	"time"
+
+	"github.com/kashifkhan0771/utils/clock"
 )
 
 // helpers
@@ -107,6 +109,51 @@ func TestDo_TotalTimeoutExceeded(t *testing.T) {
 	}
 }
 
+func TestDo_TotalTimeoutKeepsAttemptErrors(t *testing.T) {
+	opts := Options{
+		MaxAttempts:  10,
+		TotalTimeout: 50 * time.Millisecond,
+		Backoff:      FixedBackoff(time.Hour),
+	}
+
+	errTransient := errors.New("transient")
+	_, err := Do(context.Background(), opts, func(ctx context.Context) (string, error) {
+		return "", errTransient
+	})
+
+	if !errors.Is(err, context.DeadlineExceeded) || !errors.Is(err, errTransient) {
+		t.Fatalf("expected DeadlineExceeded and the attempt error, got %v", err)
+	}
+	if want := "transient\ncontext deadline exceeded"; err.Error() != want {
+		t.Fatalf("expected %q, got %q", want, err.Error())
+	}
+}
+
+func TestDo_ShouldRetryFalseKeepsAttemptErrors(t *testing.T) {
+	errTransient, errFatal := errors.New("transient"), errors.New("fatal")
+	opts := Options{
+		MaxAttempts: 5,
+		ShouldRetry: func(err error) bool { return !errors.Is(err, errFatal) },
+	}
+
+	calls := 0
+	_, err := Do(context.Background(), opts, func(ctx context.Context) (string, error) {
+		calls++
+		if calls < 3 {
+			return "", errTransient
+		}
+
+		return "", errFatal
+	})
+
+	if want := "transient\ntransient\nfatal"; err == nil || err.Error() != want {
+		t.Fatalf("expected %q, got %v", want, err)
+	}
+	if !errors.Is(err, errFatal) {
+		t.Fatalf("expected the error to match the final error, got %v", err)
+	}
+}
+
 func TestDo_ParentContextCancelled(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	opts := Options{
@@ -171,6 +218,167 @@ func TestDoVoid_RetriesAndFails(t *testing.T) {
 	}
 }
 
+func TestDo_AttemptTimeout(t *testing.T) {
+	opts := Options{
+		MaxAttempts:    3,
+		AttemptTimeout: 20 * time.Millisecond,
+		TotalTimeout:   5 * time.Second,
+	}
+
+	result, err := Do(context.Background(), opts, func(ctx context.Context) (string, error) {
+		if Attempt(ctx) < 3 {
+			<-ctx.Done() // hangs until the attempt deadline
+
+			return "", ctx.Err()
+		}
+
+		return "ok", ctx.Err()
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if result != "ok" {
+		t.Fatalf("expected 'ok', got %q", result)
+	}
+}
+
+func TestDo_AttemptInContext(t *testing.T) {
+	if got := Attempt(context.Background()); got != 0 {
+		t.Fatalf("expected 0 outside Do, got %d", got)
+	}
+
+	var seen []uint
+	_, _ = Do(context.Background(), Options{MaxAttempts: 3}, func(ctx context.Context) (string, error) {
+		seen = append(seen, Attempt(ctx))
+
+		return "", errors.New("fail")
+	})
+	if fmt.Sprint(seen) != "[1 2 3]" {
+		t.Fatalf("expected attempts [1 2 3], got %v", seen)
+	}
+}
+
+func TestDo_OnRetryAndOnGiveUp(t *testing.T) {
+	type retryEvent struct {
+		attempt uint
+		delay   time.Duration
+	}
+	var retries []retryEvent
+	var gaveUp []uint
+	var giveUpErr error
+
+	opts := Options{
+		MaxAttempts: 3,
+		Backoff:     LinearBackoff(time.Millisecond),
+		OnRetry: func(err error, attempt uint, delay time.Duration) {
+			if err == nil {
+				t.Error("expected OnRetry to receive the attempt error")
+			}
+			retries = append(retries, retryEvent{attempt: attempt, delay: delay})
+		},
+		OnGiveUp: func(err error, attempts uint) {
+			giveUpErr = err
+			gaveUp = append(gaveUp, attempts)
+		},
+	}
+
+	_, err := Do(context.Background(), opts, func(ctx context.Context) (string, error) {
+		return "", errors.New("fail")
+	})
+
+	want := []retryEvent{{attempt: 1, delay: 0}, {attempt: 2, delay: time.Millisecond}}
+	if fmt.Sprint(retries) != fmt.Sprint(want) {
+		t.Fatalf("expected retries %v, got %v", want, retries)
+	}
+	if fmt.Sprint(gaveUp) != "[3]" {
+		t.Fatalf("expected a single give up after 3 attempts, got %v", gaveUp)
+	}
+	if giveUpErr != err {
+		t.Fatalf("expected OnGiveUp to receive the returned error %v, got %v", err, giveUpErr)
+	}
+
+	retries, gaveUp = nil, nil
+	opts.ShouldRetry = func(error) bool { return false }
+	_, _ = Do(context.Background(), opts, func(ctx context.Context) (string, error) {
+		return "", errors.New("permanent")
+	})
+	if len(retries) != 0 || fmt.Sprint(gaveUp) != "[1]" {
+		t.Fatalf("expected an immediate give up, got retries %v and give ups %v", retries, gaveUp)
+	}
+
+	gaveUp = nil
+	_, _ = Do(context.Background(), opts, func(ctx context.Context) (string, error) {
+		return "ok", nil
+	})
+	if len(gaveUp) != 0 {
+		t.Fatalf("expected no give up on success, got %v", gaveUp)
+	}
+}
+
+func TestDo_NoBackoffAfterLastAttempt(t *testing.T) {
+	opts := Options{
+		MaxAttempts: 2,
+		Backoff: func(attempt uint) time.Duration {
+			if attempt == 0 {
+				return 0
+			}
+
+			return time.Hour
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	calls := 0
+	_, err := Do(ctx, opts, func(ctx context.Context) (string, error) {
+		calls++
+
+		return "", errors.New("fail")
+	})
+	if errors.Is(err, context.DeadlineExceeded) {
+		t.Fatal("expected Do to return without waiting after the last attempt")
+	}
+	if calls != 2 {
+		t.Fatalf("expected 2 calls, got %d", calls)
+	}
+}
+
+func TestDo_Clock(t *testing.T) {
+	clk := clock.NewFake(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	opts := Options{
+		MaxAttempts: 3,
+		Backoff:     ExponentialBackoff(time.Hour),
+		Clock:       clk,
+	}
+
+	fn, attempts := counter(2, "ok")
+	done := make(chan error, 1)
+	go func() {
+		_, err := Do(context.Background(), opts, fn)
+		done <- err
+	}()
+
+	// Waits 1h, then 2h, on the fake clock only
+	clk.WaitForTimers(1)
+	clk.Advance(time.Hour)
+	clk.WaitForTimers(1)
+	clk.Advance(time.Hour)
+	select {
+	case err := <-done:
+		t.Fatalf("expected Do to wait for the second backoff, returned %v", err)
+	default:
+	}
+	clk.Advance(time.Hour)
+
+	if err := <-done; err != nil {
+		t.Fatalf("expected success, got %v", err)
+	}
+	if *attempts != 3 {
+		t.Fatalf("expected 3 attempts, got %d", *attempts)
+	}
+}
+
 func TestFixedBackoff(t *testing.T) {
 	b := FixedBackoff(100 * time.Millisecond)
 	for _, attempt := range []uint{0, 1, 2, 5} {
@@ -3,6 +3,8 @@ package time
 import (
 	"fmt"
 	"time"
+
+	"github.com/kashifkhan0771/utils/clock"
 )
 
 // StartOfDay returns the start of the day for the given time
@@ -85,7 +87,13 @@ func HumanReadableDuration(d time.Duration) string {
 
 // CalculateAge calculates the age of a person given their birth date
 func CalculateAge(birthDate time.Time) int {
-	today := time.Now()
+	return CalculateAgeWithClock(birthDate, clock.New())
+}
+
+// CalculateAgeWithClock calculates the age of a person given their birth date,
+// as of the current time of c
+func CalculateAgeWithClock(birthDate time.Time, c clock.Clock) int {
+	today := clock.OrDefault(c).Now()
 	age := today.Year() - birthDate.Year()
 
 	// check if birthday has not occurred yet in this year
@@ -164,7 +172,12 @@ func FormatForDisplay(t time.Time) string {
 }
 
 func IsToday(t time.Time) bool {
-	now := time.Now()
+	return IsTodayWithClock(t, clock.New())
+}
+
+// IsTodayWithClock checks if the given time is on the same day as the current time of c
+func IsTodayWithClock(t time.Time, c clock.Clock) bool {
+	now := clock.OrDefault(c).Now()
 
 	return t.Year() == now.Year() && t.YearDay() == now.YearDay()
 }
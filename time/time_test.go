@@ -4,6 +4,8 @@ import (
 	"reflect"
 	"testing"
 	"time"
+
+	"github.com/kashifkhan0771/utils/clock"
 )
 
 // time.Now mock
@@ -1248,6 +1250,32 @@ func TestFormatForDisplay(t *testing.T) {
 	}
 }
 
+func TestCalculateAgeWithClock(t *testing.T) {
+	t.Parallel()
+
+	c := clock.NewFake(now) // 10 Jan 2025
+	tests := []struct {
+		name      string
+		birthDate time.Time
+		want      int
+	}{
+		{"Birthday today", time.Date(1995, 1, 10, 0, 0, 0, 0, time.UTC), 30},
+		{"Birthday tomorrow", time.Date(1995, 1, 11, 0, 0, 0, 0, time.UTC), 29},
+		{"Birthday yesterday", time.Date(1995, 1, 9, 0, 0, 0, 0, time.UTC), 30},
+		{"Leap day, not a leap year", time.Date(2000, 2, 29, 0, 0, 0, 0, time.UTC), 24},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := CalculateAgeWithClock(tt.birthDate, c); got != tt.want {
+				t.Errorf("CalculateAgeWithClock() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestIsToday(t *testing.T) {
 	t.Parallel()
 
@@ -1299,3 +1327,29 @@ func TestIsToday(t *testing.T) {
 		})
 	}
 }
+
+func TestIsTodayWithClock(t *testing.T) {
+	t.Parallel()
+
+	c := clock.NewFake(now.Add(23 * time.Hour)) // 10 Jan 2025, 23:00
+	tests := []struct {
+		name string
+		t    time.Time
+		want bool
+	}{
+		{"Start of day", now, true},
+		{"End of day", EndOfDay(now), true},
+		{"Next day", now.AddDate(0, 0, 1), false},
+		{"Same day, previous year", now.AddDate(-1, 0, 0), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := IsTodayWithClock(tt.t, c); got != tt.want {
+				t.Errorf("IsTodayWithClock() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}